@@ -0,0 +1,431 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/avatar"
+	"github.com/hugo-mods/discussions-bridge/pkg/cleanup"
+	"github.com/hugo-mods/discussions-bridge/pkg/config"
+	"github.com/hugo-mods/discussions-bridge/pkg/github"
+	"github.com/hugo-mods/discussions-bridge/pkg/migrate"
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+	"github.com/hugo-mods/discussions-bridge/pkg/moderation"
+	"github.com/hugo-mods/discussions-bridge/pkg/provider"
+	"github.com/hugo-mods/discussions-bridge/pkg/site"
+)
+
+// bridge holds what commands share. Everything but the config and the provider is loaded on demand.
+type bridge struct {
+	cfg      *config.Config
+	provider provider.DiscussionProvider
+
+	category   *model.Category
+	site       *site.Site
+	pages      map[string]site.Page
+	duplicates []site.Duplicate
+}
+
+// Category returns the configured category.
+func (b *bridge) Category(ctx context.Context) (*model.Category, error) {
+	if b.category != nil {
+		return b.category, nil
+	}
+	categories, err := b.provider.Categories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not retrieve categories: %w", err)
+	}
+	if len(categories) == 0 {
+		return nil, fmt.Errorf("could not find any categories. please ensure that there is at least one category")
+	}
+	b.category = categories.ByName(b.cfg.CategoryName)
+	if b.category == nil {
+		return nil, fmt.Errorf("could not find discussion with name %q", b.cfg.CategoryName)
+	}
+	fmt.Println("got category ID:", b.category.ID)
+	return b.category, nil
+}
+
+func (b *bridge) Site() (*site.Site, error) {
+	if b.site != nil {
+		return b.site, nil
+	}
+	webSite, err := site.New(b.cfg.SiteMapURL, b.cfg.SiteRSSURL, b.cfg.DiscussionOpener)
+	if err != nil {
+		return nil, fmt.Errorf("could not create site: %w", err)
+	}
+	relations, err := site.ParseRelations(config.List(b.cfg.Relations))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse relations: %w", err)
+	}
+	winner, err := site.ParseWinner(b.cfg.DuplicateWinner)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse duplicate winner: %w", err)
+	}
+	b.site = webSite.WithHTTPClient(&http.Client{Timeout: b.cfg.RequestTimeout}).
+		WithRelations(relations...).
+		WithRelationTerms(b.cfg.Terms()).
+		WithWinner(winner).
+		WithMergedDuplicates(b.cfg.MergeDuplicates)
+	return b.site, nil
+}
+
+func (b *bridge) Pages(ctx context.Context) (map[string]site.Page, error) {
+	if b.pages != nil {
+		return b.pages, nil
+	}
+	webSite, err := b.Site()
+	if err != nil {
+		return nil, err
+	}
+	pages, err := webSite.Pages(ctx, b.cfg.SiteURLPrefix)
+	if err != nil {
+		return nil, fmt.Errorf("could not get site's pages: %w", err)
+	}
+	b.pages = pages
+	return b.pages, nil
+}
+
+// Discussions returns the moderated discussions of the category related to the site's pages.
+// The pages are only fetched if they are needed for relating. Duplicates are reported and kept for cleaning up.
+func (b *bridge) Discussions(ctx context.Context) (site.Discussions, error) {
+	webSite, err := b.Site()
+	if err != nil {
+		return nil, err
+	}
+	moderated, err := b.moderatedDiscussions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	// pages are needed to adopt threads of giscus or utterances:
+	var pages map[string]site.Page
+	if webSite.NeedsPages() {
+		if pages, err = b.Pages(ctx); err != nil {
+			return nil, err
+		}
+	}
+	sds, duplicates := webSite.RelateWithDuplicates(moderated, pages)
+	for _, dup := range duplicates {
+		fmt.Printf("found %d duplicates of %s for %s\n", len(dup.Losers), dup.Winner.URL, dup.Page)
+		for _, l := range dup.Losers {
+			fmt.Printf("  %s\n", l.URL)
+		}
+	}
+	b.duplicates = duplicates
+	return sds, nil
+}
+
+func (b *bridge) moderatedDiscussions(ctx context.Context) ([]model.Discussion, error) {
+	category, err := b.Category(ctx)
+	if err != nil {
+		return nil, err
+	}
+	discussions, err := b.provider.Discussions(ctx, category.ID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get discussions for category %q: %w", category.ID, err)
+	}
+	keywords, err := moderation.Keywords(config.Lines(b.cfg.BlockedKeywords))
+	if err != nil {
+		return nil, fmt.Errorf("could not parse blocked keywords: %w", err)
+	}
+	rules := moderation.Rules{
+		BlockedLogins: config.List(b.cfg.BlockedLogins),
+		Keywords:      keywords,
+		HoldNewcomers: b.cfg.HoldNewcomers,
+		Maintainers:   config.List(b.cfg.Maintainers),
+		MaxBodyLength: b.cfg.MaxBodyLength,
+	}
+	moderated, report := rules.Apply(discussions)
+	if len(report) > 0 {
+		fmt.Printf("filtered %d comments:\n%s", len(report), report)
+	}
+	return moderated, nil
+}
+
+// syncPages creates discussions for pages that have none.
+func syncPages(ctx context.Context, b *bridge) error {
+	pages, err := b.Pages(ctx)
+	if err != nil {
+		return err
+	}
+	siteDiscussions, err := b.Discussions(ctx)
+	if err != nil {
+		return err
+	}
+	if err := handleOrphans(ctx, b, siteDiscussions, true); err != nil {
+		return err
+	}
+	webSite, err := b.Site()
+	if err != nil {
+		return err
+	}
+	category, err := b.Category(ctx)
+	if err != nil {
+		return err
+	}
+	if category.Answerable {
+		fmt.Println("category is in Q&A format: comments of created discussions can be marked as answer.")
+	}
+	var newPages []site.Page
+	for url := range pages {
+		if !siteDiscussions.HasPage(url) {
+			newPages = append(newPages, pages[url])
+		}
+	}
+	fmt.Printf("got %d pages from site. found %d unsynced discussions.\n", len(pages), len(newPages))
+	for _, p := range newPages {
+		if ctx.Err() != nil {
+			return fmt.Errorf("stopped creating discussions: %w", ctx.Err())
+		}
+		disc, err := webSite.NewDiscussion(p)
+		if err != nil {
+			fmt.Printf("could not create discussion: %v", err)
+			continue
+		}
+		if _, err := b.provider.CreateDiscussion(ctx, category.ID, disc.Title, disc.Body); err != nil {
+			fmt.Printf("could not create discussion: %v", err)
+		}
+	}
+	return cleanUpDuplicates(ctx, b)
+}
+
+// cleanUpDuplicates locks or closes the losers of duplicates if configured.
+func cleanUpDuplicates(ctx context.Context, b *bridge) error {
+	action, err := cleanup.ParseAction(b.cfg.DuplicateAction)
+	if err != nil {
+		return fmt.Errorf("could not parse duplicate action: %w", err)
+	}
+	if action == cleanup.ActionNone || len(b.duplicates) == 0 {
+		return nil
+	}
+	n, err := cleanup.New(b.provider).Duplicates(ctx, b.duplicates, action)
+	fmt.Printf("applied %s to %d duplicates.\n", action, n)
+	if err != nil {
+		fmt.Printf("could not clean up all duplicates: %v\n", err)
+	}
+	return nil
+}
+
+// export writes the discussions to the output file.
+func export(ctx context.Context, b *bridge) error {
+	siteDiscussions, err := b.Discussions(ctx)
+	if err != nil {
+		return err
+	}
+	if err := handleOrphans(ctx, b, siteDiscussions, b.cfg.EventName == "schedule"); err != nil {
+		return err
+	}
+	applyPrivacy(b.cfg, siteDiscussions)
+	if b.cfg.AvatarDir != "" {
+		if err := localizeAvatars(ctx, b.cfg, siteDiscussions); err != nil {
+			return err
+		}
+	}
+	if err := siteDiscussions.Save(b.cfg.OutputFile); err != nil {
+		return fmt.Errorf("could not save discussions: %w", err)
+	}
+	fmt.Printf("wrote %d discussions to %s\n", len(siteDiscussions), b.cfg.OutputFile)
+	return nil
+}
+
+// handleOrphans applies the orphan policy to discussions whose page has been removed for longer than the grace period.
+// Since feeds are often truncated, a page missing from it is only considered removed if it responds with 404 or 410.
+// Orphans are dropped from the discussions if configured. Other policies change discussions and are only applied
+// if act is true, i.e. on push and schedule. If the pages cannot be fetched, orphans are skipped.
+func handleOrphans(ctx context.Context, b *bridge, ds site.Discussions, act bool) error {
+	policy, err := cleanup.ParseOrphanPolicy(b.cfg.OrphanPolicy)
+	if err != nil {
+		return fmt.Errorf("could not parse orphan policy: %w", err)
+	}
+	if policy == cleanup.OrphanKeep {
+		return nil
+	}
+	pages, err := b.Pages(ctx)
+	if err != nil {
+		fmt.Printf("%v. skipping orphans.\n", err)
+		return nil
+	}
+	if len(pages) == 0 {
+		fmt.Println("got no pages from site. skipping orphans in case the site is temporarily broken.")
+		return nil
+	}
+	orphans, err := cleanup.LoadOrphans(b.provider, b.cfg.OrphanFile, b.cfg.OrphanGracePeriod)
+	if err != nil {
+		return fmt.Errorf("could not load orphans: %w", err)
+	}
+	// pages outside of the prefix are not known and therefore not removed:
+	prefixed := make(site.Discussions, len(ds))
+	for url, d := range ds {
+		if strings.HasPrefix(url, b.cfg.SiteURLPrefix) {
+			prefixed[url] = d
+		}
+	}
+	due, err := removed(ctx, b, orphans.Update(prefixed, pages))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("found %d discussions of pages missing from the site, %d of them removed for longer than %s.\n", len(prefixed.Orphans(pages)), len(due), b.cfg.OrphanGracePeriod)
+	switch {
+	case policy == cleanup.OrphanDrop:
+		cleanup.Drop(ds, due)
+	case act:
+		n, err := orphans.Apply(ctx, prefixed, due, policy, b.cfg.OrphanLabel)
+		fmt.Printf("applied orphan policy %s to %d discussions.\n", policy, n)
+		if err != nil {
+			fmt.Printf("could not handle all orphans: %v\n", err)
+		}
+	}
+	if err := orphans.Save(); err != nil {
+		return fmt.Errorf("could not save orphans: %w", err)
+	}
+	return nil
+}
+
+// removed returns the URLs of the pages that are actually gone. Pages that cannot be checked are kept.
+func removed(ctx context.Context, b *bridge, urls []string) ([]string, error) {
+	webSite, err := b.Site()
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, url := range urls {
+		gone, err := webSite.Removed(ctx, url)
+		if err != nil {
+			fmt.Printf("could not check whether %s has been removed: %v\n", url, err)
+			continue
+		}
+		if gone {
+			res = append(res, url)
+		}
+	}
+	return res, nil
+}
+
+// status reports how pages and discussions are related without changing anything.
+// The report is appended to the status file if given, e.g. $GITHUB_STEP_SUMMARY, and printed otherwise.
+func status(ctx context.Context, b *bridge) error {
+	webSite, err := b.Site()
+	if err != nil {
+		return err
+	}
+	moderated, err := b.moderatedDiscussions(ctx)
+	if err != nil {
+		return err
+	}
+	pages, err := b.Pages(ctx)
+	if err != nil {
+		return err
+	}
+	reconciliation := webSite.Reconcile(moderated, pages)
+	out := os.Stdout
+	if b.cfg.StatusFile != "" {
+		f, err := os.OpenFile(b.cfg.StatusFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+		if err != nil {
+			return fmt.Errorf("could not open status file: %w", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := reconciliation.Write(out, b.cfg.StatusFormat); err != nil {
+		return fmt.Errorf("could not write status: %w", err)
+	}
+	return nil
+}
+
+// listCategories prints the categories with their IDs.
+func listCategories(ctx context.Context, b *bridge) error {
+	categories, err := b.provider.Categories(ctx)
+	if err != nil {
+		return fmt.Errorf("could not retrieve categories: %w", err)
+	}
+	for _, c := range categories {
+		answerable := ""
+		if c.Answerable {
+			answerable = " (Q&A)"
+		}
+		fmt.Printf("  %-24s %s%s\n", c.Name, c.ID, answerable)
+	}
+	return nil
+}
+
+// migrateUtterances creates discussions for the issues of utterances. Issues are related by pathname unless configured otherwise.
+func migrateUtterances(ctx context.Context, b *bridge) error {
+	ghProvider, ok := b.provider.(*github.Provider)
+	if !ok {
+		return fmt.Errorf("migrating utterances is only supported for the github provider")
+	}
+	webSite, err := b.Site()
+	if err != nil {
+		return err
+	}
+	// utterances titles issues by pathname, while the bridge relates its own discussions by opener:
+	if !webSite.NeedsPages() {
+		webSite.WithRelations(site.RelationOpener, site.RelationPathname)
+	}
+	category, err := b.Category(ctx)
+	if err != nil {
+		return err
+	}
+	pages, err := b.Pages(ctx)
+	if err != nil {
+		return err
+	}
+	// pages that already have a discussion get the comments copied into it:
+	existing, err := b.provider.Discussions(ctx, category.ID)
+	if err != nil {
+		return fmt.Errorf("could not get discussions for category %q: %w", category.ID, err)
+	}
+	migrator, err := migrate.New(ghProvider.Client(), webSite, category.ID, b.cfg.MigrationFile)
+	if err != nil {
+		return fmt.Errorf("could not set up migration: %w", err)
+	}
+	report, err := migrator.WithLabels(config.List(b.cfg.MigrationLabels)...).WithExisting(existing).Migrate(ctx, pages)
+	fmt.Printf("migrated %d issues (%d into existing discussions), skipped %d issues migrated before. progress is recorded in %s\n",
+		len(report.Migrated), len(report.Existing), len(report.Skipped), b.cfg.MigrationFile)
+	if err != nil {
+		return fmt.Errorf("could not migrate issues: %w", err)
+	}
+	return nil
+}
+
+// applyPrivacy anonymises the user data of the discussions. It is applied after moderation and relating,
+// since these need the original authors, associations and reactions.
+func applyPrivacy(cfg *config.Config, ds site.Discussions) {
+	privacy := model.Privacy{
+		ReactionUsers:   model.ReactionUsers(cfg.ReactionUsers),
+		Salt:            cfg.PrivacySalt,
+		OmitAvatars:     cfg.OmitAvatars,
+		AvatarProxy:     cfg.AvatarProxy,
+		ServerURL:       cfg.ServerURL,
+		AnonymousLogins: config.List(cfg.AnonymousLogins),
+		StripMentions:   cfg.StripMentions,
+		StripEmails:     cfg.StripEmails,
+	}
+	privacy.Compile()
+	for url, d := range ds {
+		privacy.ApplyDiscussion(&d)
+		ds[url] = d
+	}
+}
+
+// localizeAvatars self-hosts the avatars. The index is saved even if not all avatars could be downloaded,
+// so that the next run can reuse them.
+func localizeAvatars(ctx context.Context, cfg *config.Config, ds site.Discussions) error {
+	store, err := avatar.New(&http.Client{Timeout: cfg.RequestTimeout}, cfg.AvatarDir, cfg.AvatarPublicPath(), cfg.AvatarIndexFile)
+	if err != nil {
+		return fmt.Errorf("could not create avatar store: %w", err)
+	}
+	for url, d := range ds {
+		if err := store.Localize(ctx, &d); err != nil {
+			fmt.Printf("could not self-host all avatars of %s: %v\n", url, err)
+		}
+		ds[url] = d
+	}
+	if err := store.Save(); err != nil {
+		return fmt.Errorf("could not save avatar index: %w", err)
+	}
+	return nil
+}
@@ -5,13 +5,13 @@ go 1.17
 require (
 	github.com/kdevo/config v0.0.0-20211212152733-5f7ef3589346
 	github.com/shurcooL/githubv4 v0.0.0-20211117020012-5800b9de5b8b
+	golang.org/x/net v0.0.0-20211205041911-012df41ee64c
 	golang.org/x/oauth2 v0.0.0-20211104180415-d3ed0bb246c8
 )
 
 require (
 	github.com/golang/protobuf v1.4.2 // indirect
 	github.com/shurcooL/graphql v0.0.0-20200928012149-18c5c3165e3a // indirect
-	golang.org/x/net v0.0.0-20211205041911-012df41ee64c // indirect
 	google.golang.org/appengine v1.6.6 // indirect
 	google.golang.org/protobuf v1.25.0 // indirect
 )
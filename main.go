@@ -2,93 +2,194 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"golang.org/x/oauth2"
 
 	"github.com/hugo-mods/discussions-bridge/pkg/config"
+	"github.com/hugo-mods/discussions-bridge/pkg/gitea"
 	"github.com/hugo-mods/discussions-bridge/pkg/github"
+	"github.com/hugo-mods/discussions-bridge/pkg/gitlab"
 	"github.com/hugo-mods/discussions-bridge/pkg/model"
-	"github.com/hugo-mods/discussions-bridge/pkg/site"
+	"github.com/hugo-mods/discussions-bridge/pkg/provider"
 )
 
+// commands that can be given as first argument. Without a command, it is derived from the GitHub event.
+var commands = []struct {
+	name, description string
+}{
+	{"sync-pages", "creates discussions for pages of the site that have none (event: push)"},
+	{"export", "writes the discussions related to pages to the output file (events: discussion, discussion_comment)"},
+	{"status", "reports pages without discussion, orphaned, duplicate and unrelated discussions"},
+	{"list-categories", "lists the categories discussions can be created in"},
+	{"validate-config", "checks the configuration without doing any requests"},
+	{"migrate", "creates discussions for the issues of utterances"},
+}
+
+// eventCommands map GitHub events to the commands run by default as an action.
+var eventCommands = map[string]string{
+	"push":               "sync-pages",
+	"discussion":         "export",
+	"discussion_comment": "export",
+	"issues":             "export",
+	"issue_comment":      "export",
+	"schedule":           "export",
+}
+
 func main() {
-	cfg, err := config.Load()
+	args := os.Args[1:]
+	command := ""
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		command, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("bridge", flag.ExitOnError)
+	fs.Usage = func() { usage(fs) }
+	config.Flags(fs)
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		fmt.Fprintf(fs.Output(), "unexpected arguments %q, the command must be given before the flags.\n\n", fs.Args())
+		usage(fs)
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadWithFlags(fs)
 	fmt.Println("got config:", cfg)
+	if command == "validate-config" {
+		if err != nil {
+			fmt.Printf("configuration is invalid: %s\n", err)
+			os.Exit(1)
+		}
+		fmt.Println("configuration is valid.")
+		return
+	}
 	if err != nil {
-		fatal("configuration error: %s", err)
+		exit("configuration error: %s", err)
+	}
+
+	if command == "" {
+		if eventName := cfg.EventName; eventName != "" {
+			fmt.Println("triggered by:", eventName)
+			fmt.Println("  event path:", cfg.EventPath)
+		}
+		command = eventCommands[cfg.EventName]
+		if command == "" {
+			fmt.Printf("unhandled event name %q. doing nothing.\n", cfg.EventName)
+			return
+		}
+	}
+	run, ok := map[string]func(context.Context, *bridge) error{
+		"sync-pages":      syncPages,
+		"export":          export,
+		"status":          status,
+		"list-categories": listCategories,
+		"migrate":         migrateUtterances,
+	}[command]
+	if !ok {
+		usage(fs)
+		os.Exit(2)
 	}
 
-	tokenSource := oauth2.StaticTokenSource(
-		&oauth2.Token{AccessToken: os.Getenv("REPO_TOKEN")},
-	)
-	httpClient := oauth2.NewClient(context.Background(), tokenSource)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, cfg.Timeout)
+	defer cancel()
 
-	client := github.New(httpClient, cfg.RepoOwner, cfg.RepoName)
+	b := &bridge{cfg: cfg}
+	b.provider, err = newProvider(ctx, cfg, command == "sync-pages" || command == "migrate")
+	if err != nil {
+		exit("could not set up provider: %v", err)
+	}
+	err = run(ctx, b)
 
-	categories, err := client.Categories()
+	if stats, ok := b.provider.(provider.Stats); ok {
+		fmt.Println(stats.Stats())
+	}
 	if err != nil {
-		fatal("could not retrieve categories: %v", err)
+		exit("%s failed: %v", command, err)
 	}
-	if len(categories) == 0 {
-		fatal("could not find any categories. please ensure that discussions are enabled and there is at least one category.")
+}
+
+func usage(fs *flag.FlagSet) {
+	out := fs.Output()
+	fmt.Fprintf(out, "usage: %s [command] [flags]\n\ncommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(out, "  %-16s %s\n", c.name, c.description)
 	}
-	category := categories.ByName(cfg.CategoryName, 1).First()
-	if category == nil {
-		fatal("could not find discussion with name %q", cfg.CategoryName)
+	fmt.Fprintf(out, "\nwithout command, it is derived from GITHUB_EVENT_NAME.\n\nflags:\n")
+	fs.PrintDefaults()
+}
+
+// newProvider sets up the configured provider. GitHub is additionally checked for whether it can do the work, e.g. write if needed.
+func newProvider(ctx context.Context, cfg *config.Config, write bool) (provider.DiscussionProvider, error) {
+	switch cfg.Provider {
+	case config.ProviderGitLab:
+		httpClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: os.Getenv("REPO_TOKEN")}))
+		httpClient.Timeout = cfg.RequestTimeout
+		client := gitlab.New(httpClient, cfg.GitLabURL, cfg.RepoOwner+"/"+cfg.RepoName).
+			WithAwardUsers(!cfg.ReactionCountsOnly)
+		return gitlab.NewProvider(client, modelOptions(cfg)), nil
+	case config.ProviderGitea:
+		httpClient := oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: os.Getenv("REPO_TOKEN")}))
+		httpClient.Timeout = cfg.RequestTimeout
+		client := gitea.New(httpClient, cfg.GiteaURL, cfg.RepoOwner, cfg.RepoName).
+			WithReactionUsers(!cfg.ReactionCountsOnly)
+		return gitea.NewProvider(client, modelOptions(cfg)), nil
 	}
-	fmt.Println("got category ID:", category.ID)
 
-	discussions, err := client.Discussions(category.ID)
+	tokenSource, err := newTokenSource(ctx, cfg)
 	if err != nil {
-		fatal("could not get discussions for category %q", category.ID)
+		return nil, fmt.Errorf("could not authenticate: %w", err)
 	}
+	httpClient := oauth2.NewClient(ctx, tokenSource)
+	httpClient.Timeout = cfg.RequestTimeout
 
-	webSite, err := site.New(cfg.SiteMapURL, cfg.SiteRSSURL, cfg.DiscussionOpener)
-	if err != nil {
-		fatal("could not create site: %v", err)
+	client := github.New(httpClient, cfg.RepoOwner, cfg.RepoName).
+		WithEndpoint(cfg.GraphQLURL).
+		WithBodyHTML(cfg.ExportHTML).
+		WithReactionUsers(!cfg.ReactionCountsOnly).
+		WithRetryHook(func(attempt, attempts int, wait time.Duration, err error) {
+			fmt.Printf("request failed (attempt %d/%d), retrying in %s: %v\n", attempt, attempts, wait.Round(time.Second), err)
+		}).
+		WithRateLimitHook(func(wait time.Duration, rl github.RateLimit) {
+			fmt.Printf("rate limit almost exhausted (%d points remaining), waiting %s until it resets.\n", rl.Remaining, wait.Round(time.Second))
+		})
+	if err := client.Preflight(ctx, write); err != nil {
+		return nil, fmt.Errorf("preflight check failed:\n%w", err)
 	}
-	siteDiscussions := webSite.RelateDiscussions(model.FromGitHubDiscussions(discussions))
+	return github.NewProvider(client, modelOptions(cfg)), nil
+}
 
-	if eventName := cfg.EventName; eventName != "" {
-		fmt.Println("triggered by:", eventName)
-		fmt.Println("  event path:", cfg.EventPath)
+func modelOptions(cfg *config.Config) model.Options {
+	return model.Options{
+		HiddenPlaceholders: cfg.HiddenCommentPlaceholders,
 	}
-	switch cfg.EventName {
-	case "push":
-		pages, err := webSite.Pages(cfg.SiteURLPrefix)
-		if err != nil {
-			fatal("could not get site's pages: %v", err)
-		}
+}
 
-		var newPages []site.Page
-		for url := range pages {
-			if !siteDiscussions.HasPage(url) {
-				newPages = append(newPages, pages[url])
-			}
-		}
-		fmt.Printf("got %d pages from site. found %d unsynced discussions.\n", len(pages), len(newPages))
-		for _, p := range newPages {
-			disc, err := webSite.NewDiscussion(p)
-			if err != nil {
-				fmt.Printf("could not create discussion: %v", err)
-				continue
-			}
-			if _, err := client.CreateDiscussion(category.ID, disc.Title, disc.Body); err != nil {
-				fmt.Printf("could not create discussion: %v", err)
-			}
-		}
-	case "discussion", "discussion_comment":
-		if err := siteDiscussions.Save(cfg.OutputFile); err != nil {
-			fatal("could not save discussions: %v", err)
-		}
-		fmt.Printf("wrote %d discussions to %s\n", len(siteDiscussions), cfg.OutputFile)
-	default:
-		fmt.Printf("unhandled event name %q. doing nothing.\n", cfg.EventName)
+// newTokenSource authenticates as GitHub App if configured and falls back to REPO_TOKEN otherwise.
+func newTokenSource(ctx context.Context, cfg *config.Config) (oauth2.TokenSource, error) {
+	if cfg.AppID == "" {
+		return oauth2.StaticTokenSource(
+			&oauth2.Token{AccessToken: os.Getenv("REPO_TOKEN")},
+		), nil
+	}
+	key, err := cfg.AppKey()
+	if err != nil {
+		return nil, fmt.Errorf("could not read private key of app: %w", err)
 	}
+	app := github.App{ID: cfg.AppID, InstallationID: cfg.AppInstallationID, PrivateKey: key}
+	fmt.Println("authenticating as GitHub App:", app.ID)
+	return github.NewAppTokenSource(ctx, &http.Client{Timeout: cfg.RequestTimeout}, cfg.APIURL, app, cfg.RepoOwner, cfg.RepoName)
 }
 
-func fatal(msg string, arg ...interface{}) {
-	panic(fmt.Sprintf(msg, arg...))
+// exit prints the error and exits with a non-zero code.
+func exit(msg string, arg ...interface{}) {
+	fmt.Fprintf(os.Stderr, msg+"\n", arg...)
+	os.Exit(1)
 }
@@ -0,0 +1,158 @@
+// Package avatar downloads profile pictures so that they can be served by the site itself.
+package avatar
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+)
+
+// maxSize limits the size of a single downloaded picture.
+const maxSize = 1 << 20
+
+// extensions of the accepted raster formats. SVG is not accepted since it may contain scripts.
+var extensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// Store downloads pictures to a directory and names them by their content's hash.
+// Already downloaded pictures are remembered in an index file and reused across runs.
+type Store struct {
+	dir       string
+	urlPath   string
+	indexFile string
+	client    *http.Client
+
+	// index maps the remote URL to the local file name.
+	index map[string]string
+}
+
+// New creates a store that saves pictures to dir, e.g. "static/avatars", which is served at urlPath, e.g. "/avatars/".
+func New(client *http.Client, dir string, urlPath string, indexFile string) (*Store, error) {
+	s := &Store{
+		dir:       dir,
+		urlPath:   urlPath,
+		indexFile: indexFile,
+		client:    client,
+		index:     make(map[string]string),
+	}
+	data, err := os.ReadFile(indexFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read avatar index: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.index); err != nil {
+		return nil, fmt.Errorf("could not unmarshal avatar index: %w", err)
+	}
+	return s, nil
+}
+
+// Localize rewrites the profile picture URLs of the discussion, its comments and their replies to the local copies.
+// Pictures that could not be downloaded keep their remote URL; the last error is returned.
+func (s *Store) Localize(ctx context.Context, d *model.Discussion) error {
+	var lastErr error
+	localize := func(a *model.Author) {
+		if a.PictureURL == "" {
+			return
+		}
+		local, err := s.Get(ctx, a.PictureURL)
+		if err != nil {
+			lastErr = err
+			return
+		}
+		a.PictureURL = local
+	}
+	var localizeComments func(cs []model.Comment)
+	localizeComments = func(cs []model.Comment) {
+		for i := range cs {
+			localize(&cs[i].Author)
+			localizeComments(cs[i].Comments)
+		}
+	}
+	localize(&d.Author)
+	localizeComments(d.Comments)
+	return lastErr
+}
+
+// Get returns the public path of the local copy of the picture at the given URL and downloads it if needed.
+func (s *Store) Get(ctx context.Context, url string) (string, error) {
+	if name, ok := s.index[url]; ok {
+		if _, err := os.Stat(filepath.Join(s.dir, name)); err == nil {
+			return path.Join(s.urlPath, name), nil
+		}
+	}
+	name, err := s.download(ctx, url)
+	if err != nil {
+		return "", fmt.Errorf("could not download avatar %s: %w", url, err)
+	}
+	s.index[url] = name
+	return path.Join(s.urlPath, name), nil
+}
+
+func (s *Store) download(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	ext, ok := extensions[mediaType]
+	if !ok {
+		return "", fmt.Errorf("unsupported content type %q", mediaType)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxSize+1))
+	if err != nil {
+		return "", fmt.Errorf("error while reading response: %w", err)
+	}
+	if len(data) > maxSize {
+		return "", fmt.Errorf("picture exceeds %d bytes", maxSize)
+	}
+
+	sum := sha256.Sum256(data)
+	name := hex.EncodeToString(sum[:])[:16] + ext
+	if err := os.MkdirAll(s.dir, 0777); err != nil {
+		return "", fmt.Errorf("could not create avatar directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(s.dir, name), data, 0666); err != nil {
+		return "", fmt.Errorf("could not write avatar: %w", err)
+	}
+	return name, nil
+}
+
+// Save writes the index, so that the pictures can be reused by the next run.
+func (s *Store) Save() error {
+	data, err := json.MarshalIndent(s.index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal JSON: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.indexFile), 0777); err != nil {
+		return fmt.Errorf("could not create directories to write avatar index: %v", err)
+	}
+	if err := os.WriteFile(s.indexFile, data, 0666); err != nil {
+		return fmt.Errorf("could not write avatar index: %v", err)
+	}
+	return nil
+}
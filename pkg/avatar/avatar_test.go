@@ -0,0 +1,90 @@
+package avatar_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/avatar"
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+)
+
+func TestStoreLocalize(t *testing.T) {
+	downloads := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downloads++
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("png of " + r.URL.Path))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	indexFile := filepath.Join(dir, "data", "avatars.json")
+	d := model.Discussion{
+		Message: model.Message{Author: model.Author{PictureURL: srv.URL + "/u/1"}},
+		Comments: []model.Comment{
+			{Message: model.Message{Author: model.Author{PictureURL: srv.URL + "/u/2"}}},
+			{
+				Message:  model.Message{Author: model.Author{PictureURL: srv.URL + "/u/1"}},
+				Comments: []model.Comment{{Message: model.Message{Author: model.Author{PictureURL: srv.URL + "/u/3"}}}},
+			},
+		},
+	}
+
+	store, err := avatar.New(srv.Client(), filepath.Join(dir, "static", "avatars"), "/avatars/", indexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Localize(context.Background(), &d); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if downloads != 3 {
+		t.Errorf("want each unique avatar to be downloaded once, got %d downloads", downloads)
+	}
+	local := d.Author.PictureURL
+	if !strings.HasPrefix(local, "/avatars/") || !strings.HasSuffix(local, ".png") || d.Comments[1].Author.PictureURL != local {
+		t.Errorf("unexpected local URL: %q", local)
+	}
+	if reply := d.Comments[1].Comments[0].Author.PictureURL; !strings.HasPrefix(reply, "/avatars/") || reply == local {
+		t.Errorf("want the avatar of the reply to be localized, got %q", reply)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "static", local)); err != nil {
+		t.Errorf("want avatar to be stored: %v", err)
+	}
+
+	// next run reuses the cached avatars:
+	store, err = avatar.New(srv.Client(), filepath.Join(dir, "static", "avatars"), "/avatars/", indexFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.Get(context.Background(), srv.URL+"/u/1"); err != nil || got != local {
+		t.Errorf("want cached avatar %q, got %q (err: %v)", local, got, err)
+	}
+	if downloads != 3 {
+		t.Errorf("want cached avatar to be reused, got %d downloads", downloads)
+	}
+}
+
+func TestStoreRejectsSVG(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/svg+xml")
+		w.Write([]byte(`<svg xmlns="http://www.w3.org/2000/svg"><script>alert(1)</script></svg>`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	store, err := avatar.New(srv.Client(), filepath.Join(dir, "avatars"), "/avatars/", filepath.Join(dir, "avatars.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := store.Get(context.Background(), srv.URL+"/u/1"); err == nil {
+		t.Errorf("want SVG to be rejected, got %q", got)
+	}
+}
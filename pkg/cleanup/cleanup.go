@@ -0,0 +1,99 @@
+// Package cleanup acts on discussions that should no longer be used, e.g. duplicates of a page's discussion.
+package cleanup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+	"github.com/hugo-mods/discussions-bridge/pkg/provider"
+	"github.com/hugo-mods/discussions-bridge/pkg/site"
+)
+
+// Action is what is done with a discussion that should no longer be used.
+type Action string
+
+const (
+	// ActionNone leaves the discussion as is.
+	ActionNone Action = ""
+	// ActionLock comments and locks the discussion.
+	ActionLock Action = "lock"
+	// ActionClose comments and closes the discussion.
+	ActionClose Action = "close"
+)
+
+func ParseAction(name string) (Action, error) {
+	switch a := Action(name); a {
+	case ActionNone, ActionLock, ActionClose:
+		return a, nil
+	default:
+		return "", fmt.Errorf("unknown action %q, want %s or %s", name, ActionLock, ActionClose)
+	}
+}
+
+type Cleaner struct {
+	provider provider.DiscussionProvider
+}
+
+func New(p provider.DiscussionProvider) *Cleaner {
+	return &Cleaner{provider: p}
+}
+
+// Duplicates locks or closes the losers of duplicates with a comment that links the canonical discussion.
+// Losers that have already been handled are skipped. It returns the number of handled losers.
+func (c *Cleaner) Duplicates(ctx context.Context, duplicates []site.Duplicate, action Action) (int, error) {
+	n := 0
+	for _, dup := range duplicates {
+		for _, loser := range dup.Losers {
+			if done(loser, action) {
+				continue
+			}
+			comment := fmt.Sprintf("This is a duplicate of %s, the discussion of %s. Please continue there.\n\n%s", dup.Winner.URL, dup.Page, site.BridgeMarker)
+			if err := c.apply(ctx, loser, action, comment, provider.CloseDuplicate); err != nil {
+				return n, fmt.Errorf("could not handle duplicate %s: %w", loser.URL, err)
+			}
+			n++
+		}
+	}
+	return n, nil
+}
+
+// done reports whether the action has already been applied to the discussion.
+func done(d model.Discussion, action Action) bool {
+	switch action {
+	case ActionLock:
+		return d.Locked || d.Closed
+	case ActionClose:
+		return d.Closed
+	default:
+		return true
+	}
+}
+
+// apply comments (if supported by the provider) and then applies the action.
+// The provider's support for the action is checked before anything is written.
+func (c *Cleaner) apply(ctx context.Context, d model.Discussion, action Action, comment string, reason provider.CloseReason) error {
+	var act func() error
+	switch action {
+	case ActionLock:
+		locker, ok := c.provider.(provider.Locker)
+		if !ok {
+			return fmt.Errorf("provider cannot lock discussions")
+		}
+		act = func() error { return locker.LockDiscussion(ctx, d.ID) }
+	case ActionClose:
+		closer, ok := c.provider.(provider.Closer)
+		if !ok {
+			return fmt.Errorf("provider cannot close discussions")
+		}
+		act = func() error { return closer.CloseDiscussion(ctx, d.ID, reason) }
+	default:
+		return nil
+	}
+	if commenter, ok := c.provider.(provider.Commenter); ok && comment != "" {
+		if err := commenter.AddComment(ctx, d.ID, comment); err != nil {
+			return err
+		}
+	}
+	return act()
+}
@@ -0,0 +1,143 @@
+package cleanup_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/cleanup"
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+	"github.com/hugo-mods/discussions-bridge/pkg/provider"
+	"github.com/hugo-mods/discussions-bridge/pkg/site"
+)
+
+// fakeProvider records the calls of the optional provider interfaces and applies them to its discussions, if any.
+type fakeProvider struct {
+	provider.DiscussionProvider
+	discussions []model.Discussion
+	calls       []string
+}
+
+func (p *fakeProvider) AddComment(ctx context.Context, id, body string) error {
+	p.calls = append(p.calls, "comment "+id)
+	if d := p.discussion(id); d != nil {
+		d.Comments = append(d.Comments, model.Comment{Message: model.Message{Body: body}})
+	}
+	return nil
+}
+
+func (p *fakeProvider) LockDiscussion(ctx context.Context, id string) error {
+	p.calls = append(p.calls, "lock "+id)
+	if d := p.discussion(id); d != nil {
+		d.Locked = true
+	}
+	return nil
+}
+
+func (p *fakeProvider) CloseDiscussion(ctx context.Context, id string, reason provider.CloseReason) error {
+	p.calls = append(p.calls, "close "+id+" as "+string(reason))
+	if d := p.discussion(id); d != nil {
+		d.Closed = true
+	}
+	return nil
+}
+
+func (p *fakeProvider) discussion(id string) *model.Discussion {
+	for i := range p.discussions {
+		if p.discussions[i].ID == id {
+			return &p.discussions[i]
+		}
+	}
+	return nil
+}
+
+// discussion returns a discussion of the page with the given number of comments.
+func discussion(id, page string, comments int) model.Discussion {
+	d := model.Discussion{Message: model.Message{ID: id, URL: "https://github.com/hugo-mods/discussions/" + id, Body: "Blog post: " + page}}
+	for i := 0; i < comments; i++ {
+		d.Comments = append(d.Comments, model.Comment{Message: model.Message{Body: "comment"}})
+	}
+	return d
+}
+
+func TestDuplicates(t *testing.T) {
+	const page = "https://hugo-mods.github.io/blog/icons/"
+	locked, closed := discussion("D3", page, 0), discussion("D4", page, 0)
+	locked.Locked, closed.Closed = true, true
+	duplicates := []site.Duplicate{{
+		Page:   page,
+		Winner: discussion("D1", page, 0),
+		Losers: []model.Discussion{discussion("D2", page, 0), locked, closed},
+	}}
+	testCases := []struct {
+		action    cleanup.Action
+		wantCalls []string
+	}{
+		{action: cleanup.ActionNone},
+		{action: cleanup.ActionLock, wantCalls: []string{"comment D2", "lock D2"}},
+		{action: cleanup.ActionClose, wantCalls: []string{"comment D2", "close D2 as duplicate", "comment D3", "close D3 as duplicate"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(string(tc.action), func(t *testing.T) {
+			p := &fakeProvider{}
+			n, err := cleanup.New(p).Duplicates(context.Background(), duplicates, tc.action)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tc.wantCalls, p.calls) {
+				t.Errorf("unexpected calls:\n  want=%v\n   got=%v", tc.wantCalls, p.calls)
+			}
+			if n != len(tc.wantCalls)/2 {
+				t.Errorf("want %d handled duplicates, got %d", len(tc.wantCalls)/2, n)
+			}
+		})
+	}
+}
+
+func TestDuplicatesTwice(t *testing.T) {
+	const page = "https://hugo-mods.github.io/blog/icons/"
+	// D2 wins with the most comments. After being locked with a comment, D1 has as many, but must not win.
+	p := &fakeProvider{discussions: []model.Discussion{discussion("D1", page, 1), discussion("D2", page, 2)}}
+	s, err := site.New("", "", "Blog post: {{ .URL }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.WithWinner(site.WinnerMostCommented).WithMergedDuplicates(true)
+
+	for run, wantCalls := range [][]string{{"comment D1", "lock D1"}, nil} {
+		p.calls = nil
+		sds, duplicates := s.RelateWithDuplicates(p.discussions, nil)
+		if _, err := cleanup.New(p).Duplicates(context.Background(), duplicates, cleanup.ActionLock); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(wantCalls, p.calls) {
+			t.Errorf("run %d: unexpected calls:\n  want=%v\n   got=%v", run, wantCalls, p.calls)
+		}
+		winner := sds[page]
+		if winner.ID != "D2" {
+			t.Errorf("run %d: want winner D2, got %s", run, winner.ID)
+		}
+		if len(winner.Comments) != 3 {
+			t.Errorf("run %d: want 3 merged comments without the duplicate notice, got %d", run, len(winner.Comments))
+		}
+	}
+}
+
+func TestDuplicatesUnsupported(t *testing.T) {
+	const page = "https://hugo-mods.github.io/blog/icons/"
+	p := &fakeProvider{}
+	// like Gitea, which can comment but not lock:
+	commenter := struct {
+		provider.DiscussionProvider
+		provider.Commenter
+	}{p, p}
+	duplicates := []site.Duplicate{{Page: page, Winner: discussion("D1", page, 0), Losers: []model.Discussion{discussion("D2", page, 0)}}}
+
+	if _, err := cleanup.New(commenter).Duplicates(context.Background(), duplicates, cleanup.ActionLock); err == nil {
+		t.Error("want error for a provider that cannot lock")
+	}
+	if len(p.calls) != 0 {
+		t.Errorf("want no calls before the action is known to be supported, got %v", p.calls)
+	}
+}
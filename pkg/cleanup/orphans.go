@@ -0,0 +1,164 @@
+package cleanup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/provider"
+	"github.com/hugo-mods/discussions-bridge/pkg/site"
+)
+
+// OrphanPolicy is what is done with discussions of pages that have been removed from the site.
+type OrphanPolicy string
+
+const (
+	// OrphanKeep leaves orphans as they are.
+	OrphanKeep OrphanPolicy = "keep"
+	// OrphanDrop excludes orphans from the export.
+	OrphanDrop OrphanPolicy = "drop"
+	// OrphanLabel labels orphans.
+	OrphanLabel OrphanPolicy = "label"
+	// OrphanLock comments and locks orphans.
+	OrphanLock OrphanPolicy = "lock"
+	// OrphanClose comments and closes orphans.
+	OrphanClose OrphanPolicy = "close"
+)
+
+func ParseOrphanPolicy(name string) (OrphanPolicy, error) {
+	switch p := OrphanPolicy(name); p {
+	case "":
+		return OrphanKeep, nil
+	case OrphanKeep, OrphanDrop, OrphanLabel, OrphanLock, OrphanClose:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown orphan policy %q, want keep, drop, label, lock or close", name)
+	}
+}
+
+// Orphan is a discussion whose page is no longer on the site.
+type Orphan struct {
+	Page      string    `json:"page"`
+	URL       string    `json:"url"`
+	FirstSeen time.Time `json:"firstSeen"`
+	// Handled is true if the policy has been applied.
+	Handled bool `json:"handled,omitempty"`
+}
+
+// Orphans tracks since when discussions are orphaned (by discussion ID), so that the policy is only applied after
+// a grace period. This avoids reacting to a feed that is temporarily broken or incomplete.
+type Orphans struct {
+	path     string
+	orphans  map[string]*Orphan
+	grace    time.Duration
+	now      func() time.Time
+	provider provider.DiscussionProvider
+}
+
+// LoadOrphans reads the orphans tracked in the file. A missing file results in no orphans.
+func LoadOrphans(p provider.DiscussionProvider, path string, grace time.Duration) (*Orphans, error) {
+	o := &Orphans{path: path, orphans: map[string]*Orphan{}, grace: grace, now: time.Now, provider: p}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read orphans: %w", err)
+	}
+	if err := json.Unmarshal(data, &o.orphans); err != nil {
+		return nil, fmt.Errorf("could not unmarshal orphans: %w", err)
+	}
+	return o, nil
+}
+
+// WithClock sets the function that returns the current time.
+func (o *Orphans) WithClock(now func() time.Time) *Orphans {
+	o.now = now
+	return o
+}
+
+// Update tracks the orphans among the discussions and forgets discussions whose page is back.
+// It returns the URLs of the pages whose discussions have been orphaned for longer than the grace period.
+func (o *Orphans) Update(ds site.Discussions, pages map[string]site.Page) []string {
+	now := o.now()
+	current := make(map[string]*Orphan)
+	var due []string
+	for _, url := range ds.Orphans(pages) {
+		d := ds[url]
+		orphan, ok := o.orphans[d.ID]
+		if !ok {
+			orphan = &Orphan{Page: url, URL: d.URL, FirstSeen: now}
+		}
+		current[d.ID] = orphan
+		if now.Sub(orphan.FirstSeen) >= o.grace {
+			due = append(due, url)
+		}
+	}
+	o.orphans = current
+	return due
+}
+
+// Apply applies the policy to the due orphans that have not been handled yet, except for dropping,
+// which is done by Drop. It returns the number of handled orphans.
+func (o *Orphans) Apply(ctx context.Context, ds site.Discussions, due []string, policy OrphanPolicy, label string) (int, error) {
+	if policy == OrphanKeep || policy == OrphanDrop {
+		return 0, nil
+	}
+	n := 0
+	for _, url := range due {
+		d := ds[url]
+		orphan := o.orphans[d.ID]
+		if orphan == nil || orphan.Handled {
+			continue
+		}
+		var err error
+		comment := fmt.Sprintf("The page of this discussion, %s, has been removed from the site.\n\n%s", url, site.BridgeMarker)
+		switch policy {
+		case OrphanLabel:
+			labeler, ok := o.provider.(provider.Labeler)
+			if !ok {
+				return n, fmt.Errorf("provider cannot label discussions")
+			}
+			err = labeler.AddLabel(ctx, d.ID, label)
+		case OrphanLock:
+			if !done(d, ActionLock) {
+				err = New(o.provider).apply(ctx, d, ActionLock, comment, "")
+			}
+		case OrphanClose:
+			if !done(d, ActionClose) {
+				err = New(o.provider).apply(ctx, d, ActionClose, comment, provider.CloseOutdated)
+			}
+		}
+		if err != nil {
+			return n, fmt.Errorf("could not handle orphan %s: %w", d.URL, err)
+		}
+		orphan.Handled = true
+		n++
+	}
+	return n, nil
+}
+
+// Drop removes the discussions of the due orphans.
+func Drop(ds site.Discussions, due []string) {
+	for _, url := range due {
+		delete(ds, url)
+	}
+}
+
+func (o *Orphans) Save() error {
+	data, err := json.MarshalIndent(o.orphans, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal JSON: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(o.path), 0777); err != nil {
+		return fmt.Errorf("could not create directories to write orphans: %v", err)
+	}
+	if err := os.WriteFile(o.path, data, 0666); err != nil {
+		return fmt.Errorf("could not write orphans: %v", err)
+	}
+	return nil
+}
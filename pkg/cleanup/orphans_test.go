@@ -0,0 +1,69 @@
+package cleanup_test
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/cleanup"
+	"github.com/hugo-mods/discussions-bridge/pkg/site"
+)
+
+func TestOrphans(t *testing.T) {
+	ds := site.Discussions{
+		"https://hugo-mods.github.io/blog/icons/":   discussion("D1", "https://hugo-mods.github.io/blog/icons/", 0),
+		"https://hugo-mods.github.io/blog/removed/": discussion("D2", "https://hugo-mods.github.io/blog/removed/", 0),
+	}
+	pages := map[string]site.Page{
+		"https://hugo-mods.github.io/blog/icons/": {URL: "https://hugo-mods.github.io/blog/icons/"},
+	}
+	path := filepath.Join(t.TempDir(), "orphans.json")
+	start := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := &fakeProvider{}
+
+	testCases := []struct {
+		name      string
+		after     time.Duration
+		wantDue   []string
+		wantCalls []string
+	}{
+		{name: "within grace period", after: 0},
+		{name: "after grace period", after: 25 * time.Hour, wantDue: []string{"https://hugo-mods.github.io/blog/removed/"}, wantCalls: []string{"comment D2", "close D2 as outdated"}},
+		{name: "handled before", after: 50 * time.Hour, wantDue: []string{"https://hugo-mods.github.io/blog/removed/"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p.calls = nil
+			orphans, err := cleanup.LoadOrphans(p, path, 24*time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			orphans.WithClock(func() time.Time { return start.Add(tc.after) })
+			due := orphans.Update(ds, pages)
+			if !reflect.DeepEqual(tc.wantDue, due) {
+				t.Errorf("unexpected due orphans:\n  want=%v\n   got=%v", tc.wantDue, due)
+			}
+			if _, err := orphans.Apply(context.Background(), ds, due, cleanup.OrphanClose, ""); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(tc.wantCalls, p.calls) {
+				t.Errorf("unexpected calls:\n  want=%v\n   got=%v", tc.wantCalls, p.calls)
+			}
+			if err := orphans.Save(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	dropped := site.Discussions{}
+	for url, d := range ds {
+		dropped[url] = d
+	}
+	cleanup.Drop(dropped, []string{"https://hugo-mods.github.io/blog/removed/"})
+	if len(dropped) != 1 || !dropped.HasPage("https://hugo-mods.github.io/blog/icons/") {
+		t.Errorf("want only the discussion of the existing page, got %v", dropped)
+	}
+}
@@ -2,12 +2,22 @@ package config
 
 import (
 	"encoding/json"
+	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/kdevo/config"
 	"github.com/kdevo/config/provider"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/cleanup"
+	"github.com/hugo-mods/discussions-bridge/pkg/github"
+	"github.com/hugo-mods/discussions-bridge/pkg/gitlab"
+	"github.com/hugo-mods/discussions-bridge/pkg/site"
 )
 
 type Config struct {
@@ -17,16 +27,79 @@ type Config struct {
 	CategoryName     string
 	DiscussionOpener string
 
-	OutputFile string
+	OutputFile         string
+	ExportHTML         bool
+	ReactionCountsOnly bool
+
+	HiddenCommentPlaceholders bool
+
+	BlockedLogins   string
+	BlockedKeywords string
+	HoldNewcomers   bool
+	Maintainers     string
+	MaxBodyLength   int
+
+	ReactionUsers   string
+	PrivacySalt     string
+	OmitAvatars     bool
+	AvatarProxy     string
+	AnonymousLogins string
+	StripMentions   bool
+	StripEmails     bool
+
+	AvatarDir       string
+	AvatarURLPath   string
+	AvatarIndexFile string
 
 	SiteRSSURL    string
 	SiteMapURL    string
 	SiteURLPrefix string
 
+	Relations     string
+	RelationTerms string
+
+	DuplicateWinner string
+	MergeDuplicates bool
+	DuplicateAction string
+
+	OrphanPolicy      string
+	OrphanLabel       string
+	OrphanGracePeriod time.Duration
+	OrphanFile        string
+
+	MigrationFile   string
+	MigrationLabels string
+
+	StatusFormat string
+	StatusFile   string
+
 	EventName string
 	EventPath string
+
+	Timeout        time.Duration
+	RequestTimeout time.Duration
+
+	AppID             string
+	AppInstallationID string
+	AppPrivateKey     string
+	AppPrivateKeyFile string
+
+	GraphQLURL string
+	ServerURL  string
+	APIURL     string
+
+	Provider  string
+	GiteaURL  string
+	GitLabURL string
 }
 
+// Providers that can be configured.
+const (
+	ProviderGitHub = "github"
+	ProviderGitea  = "gitea"
+	ProviderGitLab = "gitlab"
+)
+
 func (c *Config) Validate() error {
 	var errors config.Errors
 	if c.RepoName == "" {
@@ -51,9 +124,125 @@ func (c *Config) Validate() error {
 	if c.SiteRSSURL != "" && !strings.HasPrefix(c.SiteRSSURL, "http") {
 		errors.Add(config.Err("SiteRSSURL", c.SiteRSSURL, "must be a valid URL (starting with http)"))
 	}
+	for _, keyword := range Lines(c.BlockedKeywords) {
+		if _, err := regexp.Compile(keyword); err != nil {
+			errors.Add(config.Err("BlockedKeywords", keyword, "must be valid regular expressions (one per line)").WithInner(err))
+		}
+	}
+	if _, err := site.ParseRelations(List(c.Relations)); err != nil {
+		errors.Add(config.Err("Relations", c.Relations, "must be a list of opener, pathname, url, title, og:title, specific or sha1").WithInner(err))
+	}
+	if _, err := site.ParseWinner(c.DuplicateWinner); err != nil {
+		errors.Add(config.Err("DuplicateWinner", c.DuplicateWinner, "must be oldest or most-commented").WithInner(err))
+	}
+	if _, err := cleanup.ParseAction(c.DuplicateAction); err != nil {
+		errors.Add(config.Err("DuplicateAction", c.DuplicateAction, "must be empty, lock or close").WithInner(err))
+	}
+	if _, err := cleanup.ParseOrphanPolicy(c.OrphanPolicy); err != nil {
+		errors.Add(config.Err("OrphanPolicy", c.OrphanPolicy, "must be keep, drop, label, lock or close").WithInner(err))
+	}
+	if c.OrphanPolicy == string(cleanup.OrphanLabel) && c.OrphanLabel == "" {
+		errors.Add(config.EmptyErr("OrphanLabel", c.OrphanLabel))
+	}
+	if c.OrphanGracePeriod < 0 {
+		errors.Add(config.Err("OrphanGracePeriod", c.OrphanGracePeriod, "must not be negative"))
+	}
+	for _, line := range Lines(c.RelationTerms) {
+		if len(strings.Fields(line)) < 2 {
+			errors.Add(config.Err("RelationTerms", line, "must be lines of a page URL followed by its term"))
+		}
+	}
+	if c.StatusFormat != site.FormatTable && c.StatusFormat != site.FormatJSON && c.StatusFormat != site.FormatMarkdown {
+		errors.Add(config.Err("StatusFormat", c.StatusFormat, "must be table, json or markdown"))
+	}
+	if c.ReactionUsers != "" && c.ReactionUsers != "hash" && c.ReactionUsers != "omit" {
+		errors.Add(config.Err("ReactionUsers", c.ReactionUsers, "must be empty, hash or omit"))
+	}
+	if c.HoldNewcomers && c.ReactionCountsOnly {
+		errors.Add(config.Err("ReactionCountsOnly", c.ReactionCountsOnly, "must be disabled if HoldNewcomers is enabled, since held comments are released by reactions of maintainers"))
+	}
+	if c.AvatarProxy != "" && !strings.HasPrefix(c.AvatarProxy, "http") {
+		errors.Add(config.Err("AvatarProxy", c.AvatarProxy, "must be a valid URL (starting with http)"))
+	}
+	if c.AvatarDir != "" && c.AvatarPublicPath() == "" {
+		errors.Add(config.Err("AvatarURLPath", c.AvatarURLPath, "must be given if AvatarDir is not within the static directory"))
+	}
+	if c.AppID != "" && c.AppPrivateKey == "" && c.AppPrivateKeyFile == "" {
+		errors.Add(config.Err("AppPrivateKey", "", "must be given (or AppPrivateKeyFile) if AppID is set"))
+	}
+	if c.AppID == "" && (c.AppInstallationID != "" || c.AppPrivateKey != "" || c.AppPrivateKeyFile != "") {
+		errors.Add(config.EmptyErr("AppID", c.AppID))
+	}
+	if err := github.ValidateEndpoint(c.GraphQLURL); err != nil {
+		errors.Add(config.Err("GraphQLURL", c.GraphQLURL, "must be a valid GraphQL endpoint").WithInner(err))
+	}
+	if c.Provider != ProviderGitHub && c.Provider != ProviderGitea && c.Provider != ProviderGitLab {
+		errors.Add(config.Err("Provider", c.Provider, "must be github, gitea or gitlab"))
+	}
+	if c.Provider == ProviderGitea && !strings.HasPrefix(c.GiteaURL, "http") {
+		errors.Add(config.Err("GiteaURL", c.GiteaURL, "must be a valid URL (starting with http) if Provider is gitea"))
+	}
+	if c.Provider == ProviderGitLab && !strings.HasPrefix(c.GitLabURL, "http") {
+		errors.Add(config.Err("GitLabURL", c.GitLabURL, "must be a valid URL (starting with http) if Provider is gitlab"))
+	}
+	// Gitea cannot lock issues.
+	if c.Provider == ProviderGitea && c.DuplicateAction == string(cleanup.ActionLock) {
+		errors.Add(config.Err("DuplicateAction", c.DuplicateAction, "must not be lock if Provider is gitea"))
+	}
+	if c.Provider == ProviderGitea && c.OrphanPolicy == string(cleanup.OrphanLock) {
+		errors.Add(config.Err("OrphanPolicy", c.OrphanPolicy, "must not be lock if Provider is gitea"))
+	}
+	if c.Timeout <= 0 {
+		errors.Add(config.Err("Timeout", c.Timeout, "must be positive"))
+	}
+	if c.RequestTimeout <= 0 {
+		errors.Add(config.Err("RequestTimeout", c.RequestTimeout, "must be positive"))
+	}
+	if c.MaxBodyLength < 0 {
+		errors.Add(config.Err("MaxBodyLength", c.MaxBodyLength, "must not be negative"))
+	}
 	return errors.AsError()
 }
 
+// AvatarPublicPath is the path where self-hosted avatars are served.
+// If not given explicitly, it is derived from an AvatarDir within Hugo's static directory.
+func (c *Config) AvatarPublicPath() string {
+	if c.AvatarURLPath != "" {
+		return c.AvatarURLPath
+	}
+	dir := filepath.ToSlash(filepath.Clean(c.AvatarDir))
+	if strings.HasPrefix(dir, "static/") {
+		return strings.TrimPrefix(dir, "static") + "/"
+	}
+	return ""
+}
+
+// AppKey returns the GitHub App's PEM encoded private key, either given directly or read from the file.
+// Escaped newlines are supported to allow passing the key in a single line.
+func (c *Config) AppKey() ([]byte, error) {
+	if c.AppPrivateKeyFile != "" {
+		return os.ReadFile(c.AppPrivateKeyFile)
+	}
+	key := c.AppPrivateKey
+	if !strings.Contains(key, "\n") {
+		key = strings.ReplaceAll(key, `\n`, "\n")
+	}
+	return []byte(key), nil
+}
+
+// Terms returns the terms of pages for the specific relation by page URL.
+func (c *Config) Terms() map[string]string {
+	terms := make(map[string]string)
+	for _, line := range Lines(c.RelationTerms) {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		terms[fields[0]] = strings.TrimSpace(strings.TrimPrefix(line, fields[0]))
+	}
+	return terms
+}
+
 func (c *Config) Config() (interface{}, error) {
 	return c, c.Validate()
 }
@@ -63,7 +252,14 @@ func (c *Config) Name() string {
 }
 
 func (c *Config) String() string {
-	data, err := json.MarshalIndent(c, "", "  ")
+	redacted := *c
+	if redacted.PrivacySalt != "" {
+		redacted.PrivacySalt = "***"
+	}
+	if redacted.AppPrivateKey != "" {
+		redacted.AppPrivateKey = "***"
+	}
+	data, err := json.MarshalIndent(redacted, "", "  ")
 	if err != nil {
 		return fmt.Sprintf("config (unmarshal error)")
 	}
@@ -71,43 +267,214 @@ func (c *Config) String() string {
 }
 
 func Load() (*Config, error) {
-	loader := config.From(provider.Dynamic(func() (interface{}, error) {
-		return nil, nil
-	})).WithDefaults(provider.Dynamic(
+	return LoadWithFlags(nil)
+}
+
+// LoadWithFlags loads the configuration, with the values of flags (see Flags) taking precedence over the environment.
+func LoadWithFlags(fs *flag.FlagSet) (*Config, error) {
+	loader := config.From(provider.Dynamic(
 		func() (interface{}, error) {
 			var errors config.Errors
-			repo := strings.Split(os.Getenv("GITHUB_REPOSITORY"), "/")
+			// an explicitly configured endpoint takes precedence over the one of the runner:
+			graphqlURL, serverURL, apiURL := os.Getenv("GRAPHQL_URL"), os.Getenv("SERVER_URL"), ""
+			if graphqlURL == "" {
+				graphqlURL, apiURL = os.Getenv("GITHUB_GRAPHQL_URL"), os.Getenv("GITHUB_API_URL")
+				if serverURL == "" {
+					serverURL = os.Getenv("GITHUB_SERVER_URL")
+				}
+			}
+			// Gitea and Forgejo Actions provide the instance's URL like GitHub does:
+			providerName, giteaURL := os.Getenv("PROVIDER"), os.Getenv("GITEA_URL")
+			if providerName == ProviderGitea && giteaURL == "" {
+				giteaURL = serverURL
+			}
+			gitlabURL, eventName := os.Getenv("GITLAB_URL"), os.Getenv("GITHUB_EVENT_NAME")
+			repository, sep := os.Getenv("GITHUB_REPOSITORY"), "/"
+			// GitLab CI provides its own variables, and projects can be nested in groups:
+			if providerName == ProviderGitLab {
+				if gitlabURL == "" {
+					gitlabURL = os.Getenv("CI_SERVER_URL")
+				}
+				if repository == "" {
+					repository = os.Getenv("CI_PROJECT_PATH")
+				}
+				if eventName == "" {
+					eventName = os.Getenv("CI_PIPELINE_SOURCE")
+				}
+			}
 			var repoOwner, repoName string
-			if len(repo) == 2 {
-				repoOwner = repo[0]
-				repoName = repo[1]
+			if i := strings.LastIndex(repository, sep); i > 0 && i < len(repository)-1 &&
+				(providerName == ProviderGitLab || strings.Count(repository, sep) == 1) {
+				repoOwner = repository[:i]
+				repoName = repository[i+1:]
 			} else {
-				errors.Add(config.Err("RepoOwner", repo, fmt.Sprintf("env GITHUB_REPOSITORY uses incorrect format, want {owner}/{repo}")))
-				errors.Add(config.Err("RepoName", repo, fmt.Sprintf("env GITHUB_REPOSITORY uses incorrect format, want {owner}/{repo}")))
+				errors.Add(config.Err("RepoOwner", repository, fmt.Sprintf("env GITHUB_REPOSITORY uses incorrect format, want {owner}/{repo}")))
+				errors.Add(config.Err("RepoName", repository, fmt.Sprintf("env GITHUB_REPOSITORY uses incorrect format, want {owner}/{repo}")))
 			}
 			return &Config{
-				RepoOwner:        repoOwner,
-				RepoName:         repoName,
-				CategoryName:     os.Getenv("CATEGORY_NAME"),
-				DiscussionOpener: os.Getenv("DISCUSSION_OPENER"),
-				OutputFile:       os.Getenv("OUTPUT_FILE"),
+				RepoOwner:          repoOwner,
+				RepoName:           repoName,
+				CategoryName:       os.Getenv("CATEGORY_NAME"),
+				DiscussionOpener:   os.Getenv("DISCUSSION_OPENER"),
+				OutputFile:         os.Getenv("OUTPUT_FILE"),
+				ExportHTML:         parseBool(&errors, "ExportHTML", os.Getenv("EXPORT_HTML")),
+				ReactionCountsOnly: parseBool(&errors, "ReactionCountsOnly", os.Getenv("REACTION_COUNTS_ONLY")),
+
+				HiddenCommentPlaceholders: parseBool(&errors, "HiddenCommentPlaceholders", os.Getenv("HIDDEN_COMMENT_PLACEHOLDERS")),
+
+				BlockedLogins:   os.Getenv("BLOCKED_LOGINS"),
+				BlockedKeywords: os.Getenv("BLOCKED_KEYWORDS"),
+				HoldNewcomers:   parseBool(&errors, "HoldNewcomers", os.Getenv("HOLD_NEWCOMERS")),
+				Maintainers:     os.Getenv("MAINTAINERS"),
+				MaxBodyLength:   parseInt(&errors, "MaxBodyLength", os.Getenv("MAX_BODY_LENGTH")),
+
+				ReactionUsers:   os.Getenv("REACTION_USERS"),
+				PrivacySalt:     os.Getenv("PRIVACY_SALT"),
+				OmitAvatars:     parseBool(&errors, "OmitAvatars", os.Getenv("OMIT_AVATARS")),
+				AvatarProxy:     os.Getenv("AVATAR_PROXY"),
+				AnonymousLogins: os.Getenv("ANONYMOUS_LOGINS"),
+				StripMentions:   parseBool(&errors, "StripMentions", os.Getenv("STRIP_MENTIONS")),
+				StripEmails:     parseBool(&errors, "StripEmails", os.Getenv("STRIP_EMAILS")),
+
+				AvatarDir:       os.Getenv("AVATAR_DIR"),
+				AvatarURLPath:   os.Getenv("AVATAR_URL_PATH"),
+				AvatarIndexFile: os.Getenv("AVATAR_INDEX_FILE"),
 
 				SiteRSSURL:    os.Getenv("SITE_RSS_URL"),
 				SiteMapURL:    os.Getenv("SITE_MAP_URL"),
 				SiteURLPrefix: os.Getenv("SITE_URL_PREFIX"),
 
-				EventName: os.Getenv("GITHUB_EVENT_NAME"),
+				Relations:     os.Getenv("RELATIONS"),
+				RelationTerms: os.Getenv("RELATION_TERMS"),
+
+				DuplicateWinner: os.Getenv("DUPLICATE_WINNER"),
+				MergeDuplicates: parseBool(&errors, "MergeDuplicates", os.Getenv("MERGE_DUPLICATES")),
+				DuplicateAction: os.Getenv("DUPLICATE_ACTION"),
+
+				OrphanPolicy:      os.Getenv("ORPHAN_POLICY"),
+				OrphanLabel:       os.Getenv("ORPHAN_LABEL"),
+				OrphanGracePeriod: parseDuration(&errors, "OrphanGracePeriod", os.Getenv("ORPHAN_GRACE_PERIOD")),
+				OrphanFile:        os.Getenv("ORPHAN_FILE"),
+
+				MigrationFile:   os.Getenv("MIGRATION_FILE"),
+				MigrationLabels: os.Getenv("MIGRATION_LABELS"),
+
+				StatusFormat: os.Getenv("STATUS_FORMAT"),
+				StatusFile:   os.Getenv("STATUS_FILE"),
+
+				EventName: eventName,
 				EventPath: os.Getenv("GITHUB_EVENT_PATH"),
+
+				Timeout:        parseDuration(&errors, "Timeout", os.Getenv("TIMEOUT")),
+				RequestTimeout: parseDuration(&errors, "RequestTimeout", os.Getenv("REQUEST_TIMEOUT")),
+
+				AppID:             os.Getenv("APP_ID"),
+				AppInstallationID: os.Getenv("APP_INSTALLATION_ID"),
+				AppPrivateKey:     os.Getenv("APP_PRIVATE_KEY"),
+				AppPrivateKeyFile: os.Getenv("APP_PRIVATE_KEY_FILE"),
+
+				GraphQLURL: graphqlURL,
+				ServerURL:  serverURL,
+				APIURL:     apiURL,
+
+				Provider:  providerName,
+				GiteaURL:  giteaURL,
+				GitLabURL: gitlabURL,
 			}, errors.AsError()
 		},
 	).WithName("Environment")).
 		WithDefaults(&Config{
-			CategoryName:     "Blog",
-			OutputFile:       "data/discussions.json",
-			DiscussionOpener: "Blog post: {{ .URL }}",
-			SiteURLPrefix:    "http",
+			CategoryName:      "Blog",
+			OutputFile:        "data/discussions.json",
+			AvatarIndexFile:   "data/avatars.json",
+			MigrationFile:     "data/migration.json",
+			DuplicateWinner:   string(site.WinnerOldest),
+			OrphanPolicy:      string(cleanup.OrphanKeep),
+			OrphanLabel:       "orphaned",
+			OrphanGracePeriod: 72 * time.Hour,
+			OrphanFile:        "data/orphans.json",
+			StatusFormat:      site.FormatTable,
+			DiscussionOpener:  "Blog post: {{ .URL }}",
+			SiteURLPrefix:     "http",
+			Timeout:           10 * time.Minute,
+			RequestTimeout:    30 * time.Second,
+			GraphQLURL:        github.DefaultGraphQLURL,
+			Provider:          ProviderGitHub,
+			GitLabURL:         gitlab.DefaultServerURL,
 		})
 	var cfg Config
 	err := loader.Resolve(&cfg)
+	// flags are applied afterwards, since the loader treats disabled booleans as not given:
+	if fs != nil && applyFlags(fs, &cfg) {
+		err = cfg.Validate()
+	}
+	if cfg.ServerURL == "" && cfg.Provider == ProviderGitea {
+		cfg.ServerURL = cfg.GiteaURL
+	}
+	if cfg.ServerURL == "" && cfg.Provider == ProviderGitLab {
+		cfg.ServerURL = cfg.GitLabURL
+	}
+	if cfg.ServerURL == "" {
+		cfg.ServerURL = github.ServerURL(cfg.GraphQLURL)
+	}
+	if cfg.APIURL == "" {
+		cfg.APIURL = github.APIURL(cfg.GraphQLURL)
+	}
 	return &cfg, err
 }
+
+// parseBool parses an optional boolean value. An empty value is false.
+func parseBool(errors *config.Errors, field string, val string) bool {
+	if val == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		errors.Add(config.Err(field, val, "must be a boolean (true or false)").WithInner(err))
+	}
+	return b
+}
+
+// parseInt parses an optional integer value. An empty value is 0.
+func parseInt(errors *config.Errors, field string, val string) int {
+	if val == "" {
+		return 0
+	}
+	i, err := strconv.Atoi(val)
+	if err != nil {
+		errors.Add(config.Err(field, val, "must be an integer").WithInner(err))
+	}
+	return i
+}
+
+// parseDuration parses an optional duration, e.g. "30s". An empty value is 0.
+func parseDuration(errors *config.Errors, field string, val string) time.Duration {
+	if val == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(val)
+	if err != nil {
+		errors.Add(config.Err(field, val, "must be a duration, e.g. 30s or 5m").WithInner(err))
+	}
+	return d
+}
+
+// List splits a comma or newline separated value into its non-empty items.
+func List(val string) []string {
+	return split(val, func(r rune) bool { return r == ',' || r == '\n' })
+}
+
+// Lines splits a newline separated value into its non-empty lines.
+func Lines(val string) []string {
+	return split(val, func(r rune) bool { return r == '\n' })
+}
+
+func split(val string, sep func(r rune) bool) []string {
+	var items []string
+	for _, item := range strings.FieldsFunc(val, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// flags mirror the fields of Config for use outside of GitHub Actions.
+// Each flag is named after the corresponding action input and overrides its environment variable.
+var flags = []struct {
+	field, name, env string
+}{
+	{"RepoOwner", "repo-owner", "GITHUB_REPOSITORY"},
+	{"RepoName", "repo-name", "GITHUB_REPOSITORY"},
+	{"CategoryName", "category-name", "CATEGORY_NAME"},
+	{"DiscussionOpener", "discussion-opener", "DISCUSSION_OPENER"},
+	{"OutputFile", "output-file", "OUTPUT_FILE"},
+	{"ExportHTML", "export-html", "EXPORT_HTML"},
+	{"ReactionCountsOnly", "reaction-counts-only", "REACTION_COUNTS_ONLY"},
+	{"HiddenCommentPlaceholders", "hidden-comment-placeholders", "HIDDEN_COMMENT_PLACEHOLDERS"},
+	{"BlockedLogins", "blocked-logins", "BLOCKED_LOGINS"},
+	{"BlockedKeywords", "blocked-keywords", "BLOCKED_KEYWORDS"},
+	{"HoldNewcomers", "hold-newcomers", "HOLD_NEWCOMERS"},
+	{"Maintainers", "maintainers", "MAINTAINERS"},
+	{"MaxBodyLength", "max-body-length", "MAX_BODY_LENGTH"},
+	{"ReactionUsers", "reaction-users", "REACTION_USERS"},
+	{"PrivacySalt", "privacy-salt", "PRIVACY_SALT"},
+	{"OmitAvatars", "omit-avatars", "OMIT_AVATARS"},
+	{"AvatarProxy", "avatar-proxy", "AVATAR_PROXY"},
+	{"AnonymousLogins", "anonymous-logins", "ANONYMOUS_LOGINS"},
+	{"StripMentions", "strip-mentions", "STRIP_MENTIONS"},
+	{"StripEmails", "strip-emails", "STRIP_EMAILS"},
+	{"AvatarDir", "avatar-dir", "AVATAR_DIR"},
+	{"AvatarURLPath", "avatar-url-path", "AVATAR_URL_PATH"},
+	{"AvatarIndexFile", "avatar-index-file", "AVATAR_INDEX_FILE"},
+	{"SiteRSSURL", "site-rss-url", "SITE_RSS_URL"},
+	{"SiteMapURL", "site-map-url", "SITE_MAP_URL"},
+	{"SiteURLPrefix", "site-url-prefix", "SITE_URL_PREFIX"},
+	{"Relations", "relations", "RELATIONS"},
+	{"RelationTerms", "relation-terms", "RELATION_TERMS"},
+	{"DuplicateWinner", "duplicate-winner", "DUPLICATE_WINNER"},
+	{"MergeDuplicates", "merge-duplicates", "MERGE_DUPLICATES"},
+	{"DuplicateAction", "duplicate-action", "DUPLICATE_ACTION"},
+	{"OrphanPolicy", "orphan-policy", "ORPHAN_POLICY"},
+	{"OrphanLabel", "orphan-label", "ORPHAN_LABEL"},
+	{"OrphanGracePeriod", "orphan-grace-period", "ORPHAN_GRACE_PERIOD"},
+	{"OrphanFile", "orphan-file", "ORPHAN_FILE"},
+	{"MigrationFile", "migration-file", "MIGRATION_FILE"},
+	{"MigrationLabels", "migration-labels", "MIGRATION_LABELS"},
+	{"StatusFormat", "status-format", "STATUS_FORMAT"},
+	{"StatusFile", "status-file", "STATUS_FILE"},
+	{"EventName", "event-name", "GITHUB_EVENT_NAME"},
+	{"EventPath", "event-path", "GITHUB_EVENT_PATH"},
+	{"Timeout", "timeout", "TIMEOUT"},
+	{"RequestTimeout", "request-timeout", "REQUEST_TIMEOUT"},
+	{"AppID", "app-id", "APP_ID"},
+	{"AppInstallationID", "app-installation-id", "APP_INSTALLATION_ID"},
+	{"AppPrivateKey", "app-private-key", "APP_PRIVATE_KEY"},
+	{"AppPrivateKeyFile", "app-private-key-file", "APP_PRIVATE_KEY_FILE"},
+	{"GraphQLURL", "graphql-url", "GRAPHQL_URL"},
+	{"ServerURL", "server-url", "SERVER_URL"},
+	{"APIURL", "api-url", "GITHUB_API_URL"},
+	{"Provider", "provider", "PROVIDER"},
+	{"GiteaURL", "gitea-url", "GITEA_URL"},
+	{"GitLabURL", "gitlab-url", "GITLAB_URL"},
+}
+
+// Flags registers a flag for each configuration field. Pass the parsed flag set to LoadWithFlags.
+func Flags(fs *flag.FlagSet) {
+	v := reflect.ValueOf(&Config{}).Elem()
+	for _, f := range flags {
+		field := v.FieldByName(f.field)
+		if !field.IsValid() {
+			panic(fmt.Sprintf("config has no field %s", f.field))
+		}
+		usage := fmt.Sprintf("overrides env %s", f.env)
+		switch p := field.Addr().Interface().(type) {
+		case *string:
+			fs.StringVar(p, f.name, "", usage)
+		case *bool:
+			fs.BoolVar(p, f.name, false, usage)
+		case *int:
+			fs.IntVar(p, f.name, 0, usage)
+		case *time.Duration:
+			fs.DurationVar(p, f.name, 0, usage)
+		default:
+			panic(fmt.Sprintf("unsupported type of config field %s", f.field))
+		}
+	}
+}
+
+// applyFlags overrides the fields of the config with the flags that have been set explicitly,
+// so that e.g. "-export-html=false" disables what the environment enables. It reports whether any flag has been set.
+func applyFlags(fs *flag.FlagSet, cfg *Config) bool {
+	fields := make(map[string]string, len(flags))
+	for _, f := range flags {
+		fields[f.name] = f.field
+	}
+	v := reflect.ValueOf(cfg).Elem()
+	applied := false
+	fs.Visit(func(f *flag.Flag) {
+		field, ok := fields[f.Name]
+		if !ok {
+			return
+		}
+		v.FieldByName(field).Set(reflect.ValueOf(f.Value.(flag.Getter).Get()))
+		applied = true
+	})
+	return applied
+}
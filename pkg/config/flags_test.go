@@ -0,0 +1,69 @@
+package config_test
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/config"
+)
+
+func TestFlags(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "hugo-mods/hugo-mods.github.io")
+	t.Setenv("SITE_RSS_URL", "https://hugo-mods.github.io/index.xml")
+	t.Setenv("EXPORT_HTML", "true")
+	t.Setenv("HOLD_NEWCOMERS", "true")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	config.Flags(fs)
+
+	// every field needs a flag:
+	if want, got := reflect.TypeOf(config.Config{}).NumField(), countFlags(fs); want != got {
+		t.Errorf("want %d flags, got %d", want, got)
+	}
+
+	err := fs.Parse([]string{"-repo-owner", "kdevo", "-export-html=false", "-max-body-length", "100", "-timeout", "1m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadWithFlags(fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.RepoOwner != "kdevo" || cfg.RepoName != "hugo-mods.github.io" {
+		t.Errorf("want flag to override the owner only, got %s/%s", cfg.RepoOwner, cfg.RepoName)
+	}
+	if cfg.ExportHTML || !cfg.HoldNewcomers {
+		t.Errorf("want flag to disable what the environment enables, got ExportHTML=%v, HoldNewcomers=%v", cfg.ExportHTML, cfg.HoldNewcomers)
+	}
+	if cfg.MaxBodyLength != 100 || cfg.Timeout != time.Minute {
+		t.Errorf("unexpected values: MaxBodyLength=%d, Timeout=%s", cfg.MaxBodyLength, cfg.Timeout)
+	}
+}
+
+func countFlags(fs *flag.FlagSet) int {
+	n := 0
+	fs.VisitAll(func(*flag.Flag) { n++ })
+	return n
+}
+
+func TestValidateGiteaLock(t *testing.T) {
+	t.Setenv("GITHUB_REPOSITORY", "hugo-mods/hugo-mods.github.io")
+	t.Setenv("SITE_RSS_URL", "https://hugo-mods.github.io/index.xml")
+	t.Setenv("PROVIDER", config.ProviderGitea)
+	t.Setenv("GITEA_URL", "https://codeberg.org")
+
+	if _, err := config.Load(); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("ORPHAN_POLICY", "lock")
+	if _, err := config.Load(); err == nil {
+		t.Error("want error for locking orphans on Gitea")
+	}
+	t.Setenv("ORPHAN_POLICY", "close")
+	t.Setenv("DUPLICATE_ACTION", "lock")
+	if _, err := config.Load(); err == nil {
+		t.Error("want error for locking duplicates on Gitea")
+	}
+}
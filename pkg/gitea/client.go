@@ -0,0 +1,221 @@
+// Package gitea uses the issues of a Gitea or Forgejo repository as discussions.
+// A label takes the role of the discussion category.
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/rest"
+)
+
+// pageSize is the maximum number of items Gitea returns per page by default.
+const pageSize = 50
+
+type Client struct {
+	api   *rest.Client
+	owner string
+	repo  string
+
+	maxDiscussions int
+	maxComments    int
+	reactions      bool
+	reactionUsers  bool
+}
+
+// New creates a client for the instance at serverURL, e.g. "https://codeberg.org".
+// The HTTP client is expected to authenticate the requests, e.g. with an access token.
+func New(client *http.Client, serverURL, owner, repo string) *Client {
+	return &Client{
+		api:   rest.New(client, strings.TrimSuffix(serverURL, "/")+"/api/v1"),
+		owner: owner,
+		repo:  repo,
+
+		maxComments:    50,
+		maxDiscussions: 100,
+		reactions:      true,
+		reactionUsers:  true,
+	}
+}
+
+func (c *Client) WithMaxComments(n int) *Client {
+	c.maxComments = n
+	return c
+}
+
+func (c *Client) WithMaxDiscussions(n int) *Client {
+	c.maxDiscussions = n
+	return c
+}
+
+// WithReactions controls whether reactions are fetched. This takes one request per issue and comment.
+func (c *Client) WithReactions(enabled bool) *Client {
+	c.reactions = enabled
+	return c
+}
+
+// WithReactionUsers controls whether the users who reacted are exported or only the reaction counts.
+// Counting the reactions still takes the requests of WithReactions.
+func (c *Client) WithReactionUsers(enabled bool) *Client {
+	c.reactionUsers = enabled
+	return c
+}
+
+// Collaborators returns the users who have been granted access to the repository.
+func (c *Client) Collaborators(ctx context.Context) ([]User, error) {
+	var users []User
+	for page := 1; ; page++ {
+		var us []User
+		if err := c.api.Get(ctx, c.repoPath("collaborators"), url.Values{"page": {strconv.Itoa(page)}, "limit": {strconv.Itoa(pageSize)}}, &us); err != nil {
+			return nil, fmt.Errorf("could not get collaborators: %w", err)
+		}
+		users = append(users, us...)
+		if len(us) < pageSize {
+			return users, nil
+		}
+	}
+}
+
+// Labels returns all labels of the repository.
+func (c *Client) Labels(ctx context.Context) ([]Label, error) {
+	var labels []Label
+	for page := 1; ; page++ {
+		var l []Label
+		if err := c.api.Get(ctx, c.repoPath("labels"), url.Values{"page": {strconv.Itoa(page)}, "limit": {strconv.Itoa(pageSize)}}, &l); err != nil {
+			return nil, fmt.Errorf("could not get labels: %w", err)
+		}
+		labels = append(labels, l...)
+		if len(l) < pageSize {
+			return labels, nil
+		}
+	}
+}
+
+// Issues returns the open and closed issues with the label, most recently updated first.
+func (c *Client) Issues(ctx context.Context, label string) ([]Issue, error) {
+	var issues []Issue
+	for page := 1; len(issues) < c.maxDiscussions; page++ {
+		var is []Issue
+		query := url.Values{
+			"type":   {"issues"},
+			"state":  {"all"},
+			"labels": {label},
+			"sort":   {"recentupdate"},
+			"page":   {strconv.Itoa(page)},
+			"limit":  {strconv.Itoa(pageSize)},
+		}
+		if err := c.api.Get(ctx, c.repoPath("issues"), query, &is); err != nil {
+			return nil, fmt.Errorf("could not get issues: %w", err)
+		}
+		issues = append(issues, is...)
+		if len(is) < pageSize {
+			break
+		}
+	}
+	if len(issues) > c.maxDiscussions {
+		issues = issues[:c.maxDiscussions]
+	}
+	return issues, nil
+}
+
+func (c *Client) Issue(ctx context.Context, number int) (*Issue, error) {
+	var issue Issue
+	if err := c.api.Get(ctx, c.repoPath("issues", strconv.Itoa(number)), nil, &issue); err != nil {
+		return nil, fmt.Errorf("could not get issue #%d: %w", number, err)
+	}
+	return &issue, nil
+}
+
+// Thread fetches the comments of the issue and, if enabled, the reactions.
+func (c *Client) Thread(ctx context.Context, issue Issue) (*Thread, error) {
+	t := &Thread{Issue: issue, CommentReactions: map[int64][]Reaction{}}
+	if issue.Comments > 0 && c.maxComments > 0 {
+		query := url.Values{"page": {"1"}, "limit": {strconv.Itoa(c.maxComments)}}
+		if err := c.api.Get(ctx, c.repoPath("issues", strconv.Itoa(issue.Number), "comments"), query, &t.Comments); err != nil {
+			return nil, fmt.Errorf("could not get comments of issue #%d: %w", issue.Number, err)
+		}
+		if len(t.Comments) > c.maxComments {
+			t.Comments = t.Comments[:c.maxComments]
+		}
+	}
+	if !c.reactions {
+		return t, nil
+	}
+	if err := c.api.Get(ctx, c.repoPath("issues", strconv.Itoa(issue.Number), "reactions"), nil, &t.Reactions); err != nil {
+		return nil, fmt.Errorf("could not get reactions of issue #%d: %w", issue.Number, err)
+	}
+	for _, comment := range t.Comments {
+		var rs []Reaction
+		if err := c.api.Get(ctx, c.repoPath("issues", "comments", strconv.FormatInt(comment.ID, 10), "reactions"), nil, &rs); err != nil {
+			return nil, fmt.Errorf("could not get reactions of comment %d: %w", comment.ID, err)
+		}
+		t.CommentReactions[comment.ID] = rs
+	}
+	return t, nil
+}
+
+// CreateIssue creates an issue with the labels and returns it.
+func (c *Client) CreateIssue(ctx context.Context, title, body string, labelIDs ...int64) (*Issue, error) {
+	input := struct {
+		Title  string  `json:"title"`
+		Body   string  `json:"body"`
+		Labels []int64 `json:"labels,omitempty"`
+	}{Title: title, Body: body, Labels: labelIDs}
+	var issue Issue
+	if err := c.api.Send(ctx, http.MethodPost, c.repoPath("issues"), input, &issue); err != nil {
+		return nil, fmt.Errorf("could not create issue: %w", err)
+	}
+	return &issue, nil
+}
+
+// EditIssue replaces the title and body of the issue.
+func (c *Client) EditIssue(ctx context.Context, number int, title, body string) error {
+	input := struct {
+		Title string `json:"title"`
+		Body  string `json:"body"`
+	}{Title: title, Body: body}
+	if err := c.api.Send(ctx, http.MethodPatch, c.repoPath("issues", strconv.Itoa(number)), input, nil); err != nil {
+		return fmt.Errorf("could not edit issue #%d: %w", number, err)
+	}
+	return nil
+}
+
+// CloseIssue sets the issue's state to closed.
+func (c *Client) CloseIssue(ctx context.Context, number int) error {
+	input := struct {
+		State string `json:"state"`
+	}{State: "closed"}
+	if err := c.api.Send(ctx, http.MethodPatch, c.repoPath("issues", strconv.Itoa(number)), input, nil); err != nil {
+		return fmt.Errorf("could not close issue #%d: %w", number, err)
+	}
+	return nil
+}
+
+// AddLabels adds the labels to the issue.
+func (c *Client) AddLabels(ctx context.Context, number int, labelIDs ...int64) error {
+	input := struct {
+		Labels []int64 `json:"labels"`
+	}{Labels: labelIDs}
+	if err := c.api.Send(ctx, http.MethodPost, c.repoPath("issues", strconv.Itoa(number), "labels"), input, nil); err != nil {
+		return fmt.Errorf("could not label issue #%d: %w", number, err)
+	}
+	return nil
+}
+
+func (c *Client) CreateComment(ctx context.Context, number int, body string) error {
+	input := struct {
+		Body string `json:"body"`
+	}{Body: body}
+	if err := c.api.Send(ctx, http.MethodPost, c.repoPath("issues", strconv.Itoa(number), "comments"), input, nil); err != nil {
+		return fmt.Errorf("could not comment on issue #%d: %w", number, err)
+	}
+	return nil
+}
+
+func (c *Client) repoPath(elems ...string) string {
+	return "/repos/" + url.PathEscape(c.owner) + "/" + url.PathEscape(c.repo) + "/" + strings.Join(elems, "/")
+}
@@ -0,0 +1,120 @@
+package gitea
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+)
+
+// ToModelDiscussion converts the issue thread to an independent Discussion model.
+// Issues have no answers or replies to comments, so only one level of comments is converted.
+// Authors are associated with the repository by the given associations, e.g. of its owner and collaborators.
+func ToModelDiscussion(t *Thread, associations model.Associations, opts model.Options) *model.Discussion {
+	d := &model.Discussion{
+		Title: t.Issue.Title,
+		Message: model.Message{
+			ID:             strconv.Itoa(t.Issue.Number),
+			Number:         t.Issue.Number,
+			URL:            t.Issue.HTMLURL,
+			CreatedAt:      t.Issue.CreatedAt,
+			UpdatedAt:      t.Issue.UpdatedAt,
+			Author:         ToModelAuthor(t.Issue.User, associations),
+			Body:           t.Issue.Body,
+			BodyMIME:       "text/markdown",
+			Reactions:      ToModelReactions(t.Reactions),
+			ReactionCounts: ToModelReactionCounts(t.Reactions),
+		},
+		Locked: t.Issue.Locked,
+		Closed: t.Issue.State == "closed",
+	}
+	d.Comments = make([]model.Comment, 0, len(t.Comments))
+	for _, c := range t.Comments {
+		d.Comments = append(d.Comments, ToModelComment(c, t.CommentReactions[c.ID], associations))
+	}
+	return d
+}
+
+func ToModelComment(c Comment, reactions []Reaction, associations model.Associations) model.Comment {
+	var lastEditedAt *time.Time
+	if c.UpdatedAt.After(c.CreatedAt) {
+		updatedAt := c.UpdatedAt
+		lastEditedAt = &updatedAt
+	}
+	return model.Comment{
+		Message: model.Message{
+			ID:             strconv.FormatInt(c.ID, 10),
+			Number:         int(c.ID),
+			URL:            c.HTMLURL,
+			CreatedAt:      c.CreatedAt,
+			UpdatedAt:      c.UpdatedAt,
+			LastEditedAt:   lastEditedAt,
+			Edited:         lastEditedAt != nil,
+			Author:         ToModelAuthor(c.User, associations),
+			Body:           c.Body,
+			BodyMIME:       "text/markdown",
+			Reactions:      ToModelReactions(reactions),
+			ReactionCounts: ToModelReactionCounts(reactions),
+		},
+	}
+}
+
+// ghostID is the ID Gitea uses for deleted accounts.
+const ghostID = -1
+
+func ToModelAuthor(u User, associations model.Associations) model.Author {
+	return model.Author{
+		User:        model.User{Name: u.Login},
+		FullName:    u.FullName,
+		PictureURL:  u.AvatarURL,
+		ProfileURL:  u.HTMLURL,
+		Ghost:       u.ID == ghostID,
+		Association: associations.Of(u.Login),
+	}
+}
+
+// reactionContents maps Gitea's default reactions to emoji codes.
+var reactionContents = map[string]model.EmojiCode{
+	"+1":       model.ThumbsUp,
+	"-1":       model.ThumbsDown,
+	"laugh":    model.Smile,
+	"hooray":   model.Party,
+	"confused": model.Confused,
+	"heart":    model.Heart,
+	"rocket":   model.Rocket,
+	"eyes":     model.Eyes,
+}
+
+func ToModelReaction(r Reaction) model.EmojiCode {
+	code, ok := reactionContents[r.Content]
+	if !ok {
+		return model.ThoughtBalloon
+	}
+	return code
+}
+
+func ToModelReactions(rs []Reaction) model.Reactions {
+	reactions := make(model.Reactions, len(rs))
+	for _, r := range rs {
+		code := ToModelReaction(r)
+		reactions[code] = append(reactions[code], model.User{Name: r.User.Login})
+	}
+	return reactions
+}
+
+// ToModelReactionCounts counts the reactions per emoji in order of their first appearance.
+func ToModelReactionCounts(rs []Reaction) []model.ReactionCount {
+	counts := make([]model.ReactionCount, 0, len(rs))
+	index := make(map[model.EmojiCode]int, len(rs))
+	for _, r := range rs {
+		code := ToModelReaction(r)
+		i, ok := index[code]
+		if !ok {
+			i = len(counts)
+			index[code] = i
+			counts = append(counts, model.ReactionCount{Emoji: code, Char: code.Char()})
+		}
+		counts[i].Count++
+	}
+	return counts
+}
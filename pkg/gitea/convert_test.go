@@ -0,0 +1,74 @@
+package gitea_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/gitea"
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+)
+
+func TestToModelReactionCounts(t *testing.T) {
+	testCases := []struct {
+		name      string
+		reactions []string
+		want      []model.ReactionCount
+	}{
+		{name: "none", want: []model.ReactionCount{}},
+		{
+			name:      "grouped in order of appearance",
+			reactions: []string{"heart", "+1", "heart", "unknown"},
+			want: []model.ReactionCount{
+				{Emoji: model.Heart, Char: model.Heart.Char(), Count: 2},
+				{Emoji: model.ThumbsUp, Char: model.ThumbsUp.Char(), Count: 1},
+				{Emoji: model.ThoughtBalloon, Char: model.ThoughtBalloon.Char(), Count: 1},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rs := make([]gitea.Reaction, len(tc.reactions))
+			for i, content := range tc.reactions {
+				rs[i] = gitea.Reaction{Content: content}
+			}
+			got := gitea.ToModelReactionCounts(rs)
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestToModelAuthor(t *testing.T) {
+	testCases := []struct {
+		name string
+		user gitea.User
+		want model.Author
+	}{
+		{
+			name: "owner",
+			user: gitea.User{ID: 1, Login: "hugo-mods"},
+			want: model.Author{User: model.User{Name: "hugo-mods"}, Association: model.AssociationOwner},
+		},
+		{
+			name: "collaborator",
+			user: gitea.User{ID: 2, Login: "kdevo"},
+			want: model.Author{User: model.User{Name: "kdevo"}, Association: model.AssociationCollaborator},
+		},
+		{
+			name: "deleted account",
+			user: gitea.User{ID: -1, Login: "Ghost"},
+			want: model.Author{User: model.User{Name: "Ghost"}, Ghost: true, Association: model.AssociationNone},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := gitea.ToModelAuthor(tc.user, model.Associations{"hugo-mods": model.AssociationOwner, "kdevo": model.AssociationCollaborator})
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("want %+v, got %+v", tc.want, got)
+			}
+		})
+	}
+}
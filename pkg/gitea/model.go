@@ -0,0 +1,54 @@
+package gitea
+
+import "time"
+
+type User struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	FullName  string `json:"full_name"`
+	AvatarURL string `json:"avatar_url"`
+	HTMLURL   string `json:"html_url"`
+}
+
+type Label struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type Issue struct {
+	ID        int64     `json:"id"`
+	Number    int       `json:"number"`
+	HTMLURL   string    `json:"html_url"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	User      User      `json:"user"`
+	Labels    []Label   `json:"labels"`
+	State     string    `json:"state"`
+	Locked    bool      `json:"is_locked"`
+	Comments  int       `json:"comments"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Comment struct {
+	ID        int64     `json:"id"`
+	HTMLURL   string    `json:"html_url"`
+	Body      string    `json:"body"`
+	User      User      `json:"user"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Reaction struct {
+	User    User   `json:"user"`
+	Content string `json:"content"`
+}
+
+// Thread is an issue together with its comments and the reactions to both.
+type Thread struct {
+	Issue            Issue
+	Reactions        []Reaction
+	Comments         []Comment
+	CommentReactions map[int64][]Reaction
+}
@@ -0,0 +1,186 @@
+package gitea
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+	"github.com/hugo-mods/discussions-bridge/pkg/provider"
+	"github.com/hugo-mods/discussions-bridge/pkg/rest"
+)
+
+var (
+	_ provider.DiscussionProvider = (*Provider)(nil)
+	_ provider.Commenter          = (*Provider)(nil)
+	_ provider.Closer             = (*Provider)(nil)
+	_ provider.Labeler            = (*Provider)(nil)
+)
+
+// Provider serves the issues of a Gitea or Forgejo repository as independent models.
+// Categories are the repository's labels and discussion IDs are issue numbers.
+type Provider struct {
+	client       *Client
+	opts         model.Options
+	labels       []Label
+	associations model.Associations
+}
+
+func NewProvider(client *Client, opts model.Options) *Provider {
+	return &Provider{client: client, opts: opts}
+}
+
+func (p *Provider) Categories(ctx context.Context) (model.Categories, error) {
+	if err := p.loadLabels(ctx); err != nil {
+		return nil, err
+	}
+	categories := make(model.Categories, len(p.labels))
+	for i, l := range p.labels {
+		categories[i] = model.Category{ID: strconv.FormatInt(l.ID, 10), Name: l.Name}
+	}
+	return categories, nil
+}
+
+func (p *Provider) Discussions(ctx context.Context, categoryID string) ([]model.Discussion, error) {
+	label, err := p.label(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	issues, err := p.client.Issues(ctx, label.Name)
+	if err != nil {
+		return nil, err
+	}
+	ds := make([]model.Discussion, 0, len(issues))
+	for _, issue := range issues {
+		d, err := p.discussion(ctx, issue)
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, *d)
+	}
+	return ds, nil
+}
+
+func (p *Provider) Discussion(ctx context.Context, id string) (*model.Discussion, error) {
+	number, err := rest.ParseNumber(id)
+	if err != nil {
+		return nil, err
+	}
+	issue, err := p.client.Issue(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	return p.discussion(ctx, *issue)
+}
+
+func (p *Provider) discussion(ctx context.Context, issue Issue) (*model.Discussion, error) {
+	if err := p.loadAssociations(ctx); err != nil {
+		return nil, err
+	}
+	t, err := p.client.Thread(ctx, issue)
+	if err != nil {
+		return nil, err
+	}
+	d := ToModelDiscussion(t, p.associations, p.opts)
+	if !p.client.reactionUsers {
+		d.OmitReactionUsers()
+	}
+	return d, nil
+}
+
+// loadAssociations associates the repository owner and the collaborators, who are trusted like
+// collaborators on GitHub. Members of an organization who only have access through a team are not associated.
+func (p *Provider) loadAssociations(ctx context.Context) error {
+	if p.associations != nil {
+		return nil
+	}
+	collaborators, err := p.client.Collaborators(ctx)
+	if err != nil {
+		return err
+	}
+	p.associations = model.Associations{p.client.owner: model.AssociationOwner}
+	for _, u := range collaborators {
+		if u.Login != p.client.owner {
+			p.associations[u.Login] = model.AssociationCollaborator
+		}
+	}
+	return nil
+}
+
+// CreateDiscussion creates an issue with the category's label and returns its number.
+func (p *Provider) CreateDiscussion(ctx context.Context, categoryID, title, body string) (string, error) {
+	label, err := p.label(ctx, categoryID)
+	if err != nil {
+		return "", err
+	}
+	issue, err := p.client.CreateIssue(ctx, title, body, label.ID)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(issue.Number), nil
+}
+
+func (p *Provider) UpdateDiscussion(ctx context.Context, id, title, body string) error {
+	number, err := rest.ParseNumber(id)
+	if err != nil {
+		return err
+	}
+	return p.client.EditIssue(ctx, number, title, body)
+}
+
+func (p *Provider) AddComment(ctx context.Context, id, body string) error {
+	number, err := rest.ParseNumber(id)
+	if err != nil {
+		return err
+	}
+	return p.client.CreateComment(ctx, number, body)
+}
+
+// CloseDiscussion closes the issue. Issues have no close reason.
+func (p *Provider) CloseDiscussion(ctx context.Context, id string, reason provider.CloseReason) error {
+	number, err := rest.ParseNumber(id)
+	if err != nil {
+		return err
+	}
+	return p.client.CloseIssue(ctx, number)
+}
+
+func (p *Provider) AddLabel(ctx context.Context, id, label string) error {
+	number, err := rest.ParseNumber(id)
+	if err != nil {
+		return err
+	}
+	if err := p.loadLabels(ctx); err != nil {
+		return err
+	}
+	for _, l := range p.labels {
+		if l.Name == label {
+			return p.client.AddLabels(ctx, number, l.ID)
+		}
+	}
+	return fmt.Errorf("could not find label %q", label)
+}
+
+func (p *Provider) loadLabels(ctx context.Context) error {
+	if p.labels != nil {
+		return nil
+	}
+	labels, err := p.client.Labels(ctx)
+	if err != nil {
+		return err
+	}
+	p.labels = labels
+	return nil
+}
+
+func (p *Provider) label(ctx context.Context, id string) (*Label, error) {
+	if err := p.loadLabels(ctx); err != nil {
+		return nil, err
+	}
+	for i := range p.labels {
+		if strconv.FormatInt(p.labels[i].ID, 10) == id {
+			return &p.labels[i], nil
+		}
+	}
+	return nil, fmt.Errorf("could not find label with ID %s", id)
+}
@@ -0,0 +1,96 @@
+package gitea_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/gitea"
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+)
+
+func TestProvider(t *testing.T) {
+	var created map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v1/repos/hugo-mods/blog/labels", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": 7, "name": "Blog"}]`))
+	})
+	mux.HandleFunc("/api/v1/repos/hugo-mods/blog/issues", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			json.NewDecoder(r.Body).Decode(&created)
+			w.Write([]byte(`{"number": 2}`))
+			return
+		}
+		if got := r.URL.Query().Get("labels"); got != "Blog" {
+			t.Errorf("want issues filtered by label Blog, got %q", got)
+		}
+		w.Write([]byte(`[{"number": 1, "title": "Hello", "body": "Blog post: https://example.com/hello/", "comments": 1, "user": {"id": 1, "login": "hugo-mods"}}]`))
+	})
+	mux.HandleFunc("/api/v1/repos/hugo-mods/blog/collaborators", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": 3, "login": "kdevo"}]`))
+	})
+	mux.HandleFunc("/api/v1/repos/hugo-mods/blog/issues/1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"number": 1, "title": "Hello", "comments": 2, "user": {"id": 1, "login": "hugo-mods"}}`))
+	})
+	mux.HandleFunc("/api/v1/repos/hugo-mods/blog/issues/1/comments", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id": 11, "body": "Nice!", "user": {"id": 2, "login": "reader"}}, {"id": 12, "body": "Thanks!", "user": {"id": 3, "login": "kdevo"}}]`))
+	})
+	mux.HandleFunc("/api/v1/repos/hugo-mods/blog/issues/1/reactions", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"content": "heart", "user": {"login": "reader"}}]`))
+	})
+	mux.HandleFunc("/api/v1/repos/hugo-mods/blog/issues/comments/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	p := gitea.NewProvider(gitea.New(srv.Client(), srv.URL, "hugo-mods", "blog"), model.Options{})
+	ctx := context.Background()
+	categories, err := p.Categories(ctx)
+	if err != nil {
+		t.Fatalf("could not get categories: %v", err)
+	}
+	category := categories.ByName("Blog")
+	if category == nil || category.ID != "7" {
+		t.Fatalf("want label Blog with ID 7, got %+v", categories)
+	}
+
+	ds, err := p.Discussions(ctx, category.ID)
+	if err != nil {
+		t.Fatalf("could not get discussions: %v", err)
+	}
+	if len(ds) != 1 || ds[0].ID != "1" || len(ds[0].Comments) != 2 {
+		t.Fatalf("want issue #1 with two comments, got %+v", ds)
+	}
+	if got := ds[0].Reactions[model.Heart]; len(got) != 1 || got[0].Name != "reader" {
+		t.Errorf("want heart reaction of reader, got %v", ds[0].Reactions)
+	}
+	if got := ds[0].Author.Association; got != model.AssociationOwner {
+		t.Errorf("want owner association, got %q", got)
+	}
+	if got := []model.Association{ds[0].Comments[0].Author.Association, ds[0].Comments[1].Author.Association}; got[0] != model.AssociationNone || got[1] != model.AssociationCollaborator {
+		t.Errorf("want reader without and kdevo as collaborator, got %q", got)
+	}
+
+	id, err := p.CreateDiscussion(ctx, category.ID, "New", "Blog post: https://example.com/new/")
+	if err != nil {
+		t.Fatalf("could not create discussion: %v", err)
+	}
+	if id != "2" {
+		t.Errorf("want issue number 2, got %q", id)
+	}
+	if labels, _ := created["labels"].([]interface{}); len(labels) != 1 || labels[0] != float64(7) {
+		t.Errorf("want issue created with label 7, got %v", created["labels"])
+	}
+
+	counts := gitea.NewProvider(gitea.New(srv.Client(), srv.URL, "hugo-mods", "blog").WithReactionUsers(false), model.Options{})
+	d, err := counts.Discussion(ctx, "1")
+	if err != nil {
+		t.Fatalf("could not get discussion: %v", err)
+	}
+	if len(d.Reactions) != 0 || len(d.ReactionCounts) != 1 {
+		t.Errorf("want only reaction counts, got %v and %v", d.Reactions, d.ReactionCounts)
+	}
+}
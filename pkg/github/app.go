@@ -0,0 +1,157 @@
+package github
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// App are the credentials of a GitHub App, used to act under the app's identity.
+type App struct {
+	ID string
+	// InstallationID is optional. If empty, the installation is looked up by repository.
+	InstallationID string
+	// PrivateKey is the PEM encoded private key of the app.
+	PrivateKey []byte
+}
+
+// appTokenSource exchanges a JWT signed by the app for an installation access token.
+type appTokenSource struct {
+	ctx    context.Context
+	client *http.Client
+	apiURL string
+	app    App
+	key    *rsa.PrivateKey
+	owner  string
+	repo   string
+}
+
+// NewAppTokenSource returns a token source for installation access tokens of the app.
+// Tokens are cached and refreshed a few minutes before they expire.
+func NewAppTokenSource(ctx context.Context, client *http.Client, apiURL string, app App, owner, repo string) (oauth2.TokenSource, error) {
+	key, err := parsePrivateKey(app.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse private key of app %s: %w", app.ID, err)
+	}
+	return oauth2.ReuseTokenSource(nil, &appTokenSource{
+		ctx:    ctx,
+		client: client,
+		apiURL: strings.TrimSuffix(apiURL, "/"),
+		app:    app,
+		key:    key,
+		owner:  owner,
+		repo:   repo,
+	}), nil
+}
+
+func (s *appTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := s.jwt(time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("could not sign JWT: %w", err)
+	}
+	installationID := s.app.InstallationID
+	if installationID == "" {
+		var installation struct {
+			ID int64 `json:"id"`
+		}
+		url := fmt.Sprintf("%s/repos/%s/%s/installation", s.apiURL, s.owner, s.repo)
+		if err := s.do(http.MethodGet, url, jwt, &installation); err != nil {
+			return nil, fmt.Errorf("could not find installation of app %s for %s/%s: %w", s.app.ID, s.owner, s.repo, err)
+		}
+		installationID = fmt.Sprint(installation.ID)
+		s.app.InstallationID = installationID
+	}
+
+	var token struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	url := fmt.Sprintf("%s/app/installations/%s/access_tokens", s.apiURL, installationID)
+	if err := s.do(http.MethodPost, url, jwt, &token); err != nil {
+		return nil, fmt.Errorf("could not create installation access token: %w", err)
+	}
+	return &oauth2.Token{
+		AccessToken: token.Token,
+		TokenType:   "token",
+		Expiry:      token.ExpiresAt.Add(-5 * time.Minute),
+	}, nil
+}
+
+func (s *appTokenSource) do(method, url, jwt string, v interface{}) error {
+	req, err := http.NewRequestWithContext(s.ctx, method, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error while reading response: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, body)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// jwt creates a JSON Web Token that authenticates the app itself.
+// It is backdated to allow for clock drift and expires within GitHub's maximum of 10 minutes.
+func (s *appTokenSource) jwt(now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-1 * time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": s.app.ID,
+	})
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(signature), nil
+}
+
+func parsePrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
@@ -0,0 +1,70 @@
+package github_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/github"
+)
+
+func TestAppTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pemKey := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		jwt := strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), ".")
+		if len(jwt) != 3 {
+			t.Errorf("unexpected authorization: %q", r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		signature, _ := base64.RawURLEncoding.DecodeString(jwt[2])
+		digest := sha256.Sum256([]byte(jwt[0] + "." + jwt[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+			t.Errorf("invalid JWT signature: %v", err)
+		}
+		switch r.URL.Path {
+		case "/repos/hugo-mods/hugo-mods.github.io/installation":
+			w.Write([]byte(`{"id": 42}`))
+		case "/app/installations/42/access_tokens":
+			w.Write([]byte(`{"token": "ghs_test", "expires_at": "2099-01-01T00:00:00Z"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	app := github.App{ID: "1234", PrivateKey: pemKey}
+	ts, err := github.NewAppTokenSource(context.Background(), srv.Client(), srv.URL, app, "hugo-mods", "hugo-mods.github.io")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		token, err := ts.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.AccessToken != "ghs_test" {
+			t.Errorf("unexpected token: %q", token.AccessToken)
+		}
+	}
+	want := []string{"GET /repos/hugo-mods/hugo-mods.github.io/installation", "POST /app/installations/42/access_tokens"}
+	if strings.Join(paths, ",") != strings.Join(want, ",") {
+		t.Errorf("want token to be cached after lookup:\n  want=%v\n   got=%v", want, paths)
+	}
+}
@@ -3,32 +3,70 @@ package github
 import (
 	"context"
 	"fmt"
+	"math/rand"
 	"net/http"
+	"time"
 
 	"github.com/shurcooL/githubv4"
 )
 
 type Client struct {
-	gql   *githubv4.Client
-	owner string
-	repo  string
+	gql        *githubv4.Client
+	httpClient *http.Client
+	transport  *rateLimitTransport
+	endpoint   string
+	owner      string
+	repo       string
 
 	maxDiscussions int
 	maxComments    int
+	bodyHTML       bool
+	reactionUsers  bool
+
+	maxRetries       int
+	mutationInterval time.Duration
+	lastMutation     time.Time
+	rand             *rand.Rand
+	onRetry          func(attempt, attempts int, wait time.Duration, err error)
+	onRateLimit      func(wait time.Duration, rl RateLimit)
+
+	cost      int
+	rateLimit RateLimit
+
+	repository *Repository
+	categories Categories
 }
 
 func New(client *http.Client, owner string, repo string) *Client {
-	gql := githubv4.NewClient(client)
+	transport := newRateLimitTransport(client.Transport)
+	wrapped := *client
+	wrapped.Transport = transport
+	gql := githubv4.NewClient(&wrapped)
 	return &Client{
-		gql:   gql,
-		owner: owner,
-		repo:  repo,
+		gql:        gql,
+		httpClient: &wrapped,
+		transport:  transport,
+		endpoint:   DefaultGraphQLURL,
+		owner:      owner,
+		repo:       repo,
 
 		maxComments:    50,
 		maxDiscussions: 100,
+		reactionUsers:  true,
+
+		maxRetries:       5,
+		mutationInterval: 1 * time.Second,
+		rand:             newRand(),
 	}
 }
 
+// WithEndpoint sets the GraphQL endpoint, e.g. "https://github.example.com/api/graphql" for GitHub Enterprise Server.
+func (c *Client) WithEndpoint(url string) *Client {
+	c.gql = githubv4.NewEnterpriseClient(url, c.httpClient)
+	c.endpoint = url
+	return c
+}
+
 func (c *Client) WithMaxComments(n int) *Client {
 	c.maxComments = n
 	return c
@@ -39,7 +77,43 @@ func (c *Client) WithMaxDiscussions(n int) *Client {
 	return c
 }
 
-func (c *Client) Discussions(categoryID string) ([]Discussion, error) {
+// WithMaxRetries sets how often failed requests are retried.
+func (c *Client) WithMaxRetries(n int) *Client {
+	c.maxRetries = n
+	return c
+}
+
+// WithRetryHook sets a function that is called before a failed request is retried, e.g. for logging.
+func (c *Client) WithRetryHook(fn func(attempt, attempts int, wait time.Duration, err error)) *Client {
+	c.onRetry = fn
+	return c
+}
+
+// WithRateLimitHook sets a function that is called before waiting for the rate limit to reset, e.g. for logging.
+func (c *Client) WithRateLimitHook(fn func(wait time.Duration, rl RateLimit)) *Client {
+	c.onRateLimit = fn
+	return c
+}
+
+// WithMutationInterval sets the minimum interval between mutations.
+func (c *Client) WithMutationInterval(d time.Duration) *Client {
+	c.mutationInterval = d
+	return c
+}
+
+// WithBodyHTML additionally fetches the HTML that GitHub renders for discussion and comment bodies.
+func (c *Client) WithBodyHTML(enabled bool) *Client {
+	c.bodyHTML = enabled
+	return c
+}
+
+// WithReactionUsers controls whether the users who reacted are fetched. Reaction counts are always fetched.
+func (c *Client) WithReactionUsers(enabled bool) *Client {
+	c.reactionUsers = enabled
+	return c
+}
+
+func (c *Client) Discussions(ctx context.Context, categoryID string) ([]Discussion, error) {
 	var q struct {
 		Repository struct {
 			Discussions struct {
@@ -47,61 +121,199 @@ func (c *Client) Discussions(categoryID string) ([]Discussion, error) {
 				TotalCount int
 			} `graphql:"discussions(first: $firstDiscussions, categoryId: $categoryID, orderBy: {field: UPDATED_AT, direction: DESC})"`
 		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit RateLimit
 	}
-	err := c.gql.Query(context.Background(), &q,
+	err := c.query(ctx, &q,
 		map[string]interface{}{
-			"owner":            githubv4.String(c.owner),
-			"name":             githubv4.String(c.repo),
-			"categoryID":       githubv4.ID(categoryID),
-			"firstDiscussions": githubv4.Int(c.maxDiscussions),
-			"firstComments":    githubv4.Int(c.maxComments),
-			"firstReactions":   githubv4.Int(50),
+			"owner":             githubv4.String(c.owner),
+			"name":              githubv4.String(c.repo),
+			"categoryID":        githubv4.ID(categoryID),
+			"firstDiscussions":  githubv4.Int(c.maxDiscussions),
+			"firstComments":     githubv4.Int(c.maxComments),
+			"firstReactions":    githubv4.Int(50),
+			"withBodyHTML":      githubv4.Boolean(c.bodyHTML),
+			"withReactionUsers": githubv4.Boolean(c.reactionUsers),
 		},
 	)
 	if err != nil {
 		return nil, err
 	}
+	c.track(q.RateLimit)
 	return q.Repository.Discussions.Nodes, nil
 }
 
-func (c *Client) Categories() (Categories, error) {
+// Discussion returns a single discussion by its node ID.
+func (c *Client) Discussion(ctx context.Context, id string) (*Discussion, error) {
 	var q struct {
-		Repository struct {
-			DiscussionCategories struct {
-				Nodes      []Category
-				TotalCount int
-			} `graphql:"discussionCategories(first: $n)"`
-		} `graphql:"repository(owner: $owner, name: $name)"`
+		Node struct {
+			Discussion Discussion `graphql:"... on Discussion"`
+		} `graphql:"node(id: $id)"`
+		RateLimit RateLimit
 	}
-	err := c.gql.Query(context.Background(), &q,
+	err := c.query(ctx, &q,
 		map[string]interface{}{
-			"owner": githubv4.String(c.owner),
-			"name":  githubv4.String(c.repo),
-			"n":     githubv4.Int(100),
+			"id":                githubv4.ID(id),
+			"firstComments":     githubv4.Int(c.maxComments),
+			"firstReactions":    githubv4.Int(50),
+			"withBodyHTML":      githubv4.Boolean(c.bodyHTML),
+			"withReactionUsers": githubv4.Boolean(c.reactionUsers),
 		},
 	)
 	if err != nil {
 		return nil, err
 	}
-	return Categories(q.Repository.DiscussionCategories.Nodes), nil
+	c.track(q.RateLimit)
+	if q.Node.Discussion.ID == "" {
+		return nil, fmt.Errorf("could not find discussion %q", id)
+	}
+	return &q.Node.Discussion, nil
+}
+
+// Issues returns the repository's open and closed issues with all their comments, oldest first.
+// If labels are given, only issues with any of them are returned.
+func (c *Client) Issues(ctx context.Context, labels ...string) ([]Issue, error) {
+	var labelFilter *[]githubv4.String
+	if len(labels) > 0 {
+		ls := make([]githubv4.String, len(labels))
+		for i, l := range labels {
+			ls[i] = githubv4.String(l)
+		}
+		labelFilter = &ls
+	}
+	var issues []Issue
+	var cursor *githubv4.String
+	for {
+		var q struct {
+			Repository struct {
+				Issues struct {
+					Nodes    []Issue
+					PageInfo struct {
+						EndCursor   githubv4.String
+						HasNextPage bool
+					}
+				} `graphql:"issues(first: 50, after: $cursor, labels: $labels, orderBy: {field: CREATED_AT, direction: ASC})"`
+			} `graphql:"repository(owner: $owner, name: $name)"`
+			RateLimit RateLimit
+		}
+		err := c.query(ctx, &q,
+			map[string]interface{}{
+				"owner":  githubv4.String(c.owner),
+				"name":   githubv4.String(c.repo),
+				"cursor": cursor,
+				"labels": labelFilter,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		c.track(q.RateLimit)
+		for i := range q.Repository.Issues.Nodes {
+			if err := c.issueComments(ctx, &q.Repository.Issues.Nodes[i]); err != nil {
+				return nil, err
+			}
+		}
+		issues = append(issues, q.Repository.Issues.Nodes...)
+		if !q.Repository.Issues.PageInfo.HasNextPage {
+			return issues, nil
+		}
+		endCursor := q.Repository.Issues.PageInfo.EndCursor
+		cursor = &endCursor
+	}
+}
+
+// issueComments fetches the comments of the issue that have not been fetched with it.
+func (c *Client) issueComments(ctx context.Context, issue *Issue) error {
+	for issue.Comments.PageInfo.HasNextPage {
+		var q struct {
+			Node struct {
+				Issue struct {
+					Comments IssueComments `graphql:"comments(first: 100, after: $cursor)"`
+				} `graphql:"... on Issue"`
+			} `graphql:"node(id: $id)"`
+			RateLimit RateLimit
+		}
+		err := c.query(ctx, &q,
+			map[string]interface{}{
+				"id":     githubv4.ID(issue.ID),
+				"cursor": githubv4.String(issue.Comments.PageInfo.EndCursor),
+			},
+		)
+		if err != nil {
+			return fmt.Errorf("could not get comments of issue #%d: %w", issue.Number, err)
+		}
+		c.track(q.RateLimit)
+		comments := q.Node.Issue.Comments
+		issue.Comments.Nodes = append(issue.Comments.Nodes, comments.Nodes...)
+		issue.Comments.PageInfo = comments.PageInfo
+		if len(comments.Nodes) == 0 {
+			break
+		}
+	}
+	if len(issue.Comments.Nodes) < issue.Comments.TotalCount {
+		return fmt.Errorf("could only get %d of %d comments of issue #%d", len(issue.Comments.Nodes), issue.Comments.TotalCount, issue.Number)
+	}
+	return nil
+}
+
+// Repository returns the repository. It is fetched only once per client, together with the categories.
+func (c *Client) Repository(ctx context.Context) (*Repository, error) {
+	if err := c.loadRepository(ctx); err != nil {
+		return nil, err
+	}
+	return c.repository, nil
+}
+
+// Categories returns the repository's discussion categories. They are fetched only once per client.
+func (c *Client) Categories(ctx context.Context) (Categories, error) {
+	if err := c.loadRepository(ctx); err != nil {
+		return nil, err
+	}
+	return c.categories, nil
 }
 
-func (c *Client) CreateDiscussion(categoryID, title, body string) (string, error) {
+func (c *Client) loadRepository(ctx context.Context) error {
+	if c.repository != nil {
+		return nil
+	}
 	var q struct {
 		Repository struct {
-			ID string
+			ID                    string
+			NameWithOwner         string
+			HasDiscussionsEnabled bool
+			ViewerPermission      string
+			DiscussionCategories  struct {
+				Nodes      []Category
+				TotalCount int
+			} `graphql:"discussionCategories(first: $n)"`
 		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit RateLimit
 	}
-	err := c.gql.Query(context.Background(), &q,
+	err := c.query(ctx, &q,
 		map[string]interface{}{
 			"owner": githubv4.String(c.owner),
 			"name":  githubv4.String(c.repo),
+			"n":     githubv4.Int(100),
 		},
 	)
+	if err != nil {
+		return err
+	}
+	c.track(q.RateLimit)
+	c.repository = &Repository{
+		ID:                    q.Repository.ID,
+		NameWithOwner:         q.Repository.NameWithOwner,
+		HasDiscussionsEnabled: q.Repository.HasDiscussionsEnabled,
+		ViewerPermission:      q.Repository.ViewerPermission,
+	}
+	c.categories = Categories(q.Repository.DiscussionCategories.Nodes)
+	return nil
+}
+
+func (c *Client) CreateDiscussion(ctx context.Context, categoryID, title, body string) (string, error) {
+	repository, err := c.Repository(ctx)
 	if err != nil {
 		return "", fmt.Errorf("could not to get repository ID: %v", err)
 	}
-	repositoryID := q.Repository.ID
 
 	var m struct {
 		CreateDiscussion struct {
@@ -111,14 +323,154 @@ func (c *Client) CreateDiscussion(categoryID, title, body string) (string, error
 		} `graphql:"createDiscussion(input: $input)"`
 	}
 	input := githubv4.CreateDiscussionInput{
-		RepositoryID: githubv4.ID(repositoryID),
+		RepositoryID: githubv4.ID(repository.ID),
 		CategoryID:   githubv4.ID(categoryID),
 		Title:        githubv4.String(title),
 		Body:         githubv4.String(body),
 	}
-	err = c.gql.Mutate(context.Background(), &m, input, nil)
+	err = c.mutate(ctx, &m, input)
 	if err != nil {
 		return "", fmt.Errorf("could not create discussion: %v", err)
 	}
 	return m.CreateDiscussion.Discussion.ID, nil
 }
+
+func (c *Client) UpdateDiscussion(ctx context.Context, id, title, body string) error {
+	var m struct {
+		UpdateDiscussion struct {
+			Discussion struct {
+				ID string
+			}
+		} `graphql:"updateDiscussion(input: $input)"`
+	}
+	t, b := githubv4.String(title), githubv4.String(body)
+	input := githubv4.UpdateDiscussionInput{
+		DiscussionID: githubv4.ID(id),
+		Title:        &t,
+		Body:         &b,
+	}
+	if err := c.mutate(ctx, &m, input); err != nil {
+		return fmt.Errorf("could not update discussion: %v", err)
+	}
+	return nil
+}
+
+// AddDiscussionComment adds a comment to the discussion and returns the comment's ID.
+func (c *Client) AddDiscussionComment(ctx context.Context, discussionID, body string) (string, error) {
+	var m struct {
+		AddDiscussionComment struct {
+			Comment struct {
+				ID string
+			}
+		} `graphql:"addDiscussionComment(input: $input)"`
+	}
+	input := githubv4.AddDiscussionCommentInput{
+		DiscussionID: githubv4.ID(discussionID),
+		Body:         githubv4.String(body),
+	}
+	if err := c.mutate(ctx, &m, input); err != nil {
+		return "", fmt.Errorf("could not add comment: %v", err)
+	}
+	return m.AddDiscussionComment.Comment.ID, nil
+}
+
+// LockDiscussion locks the discussion (or any other lockable), so that only maintainers can comment.
+func (c *Client) LockDiscussion(ctx context.Context, id string) error {
+	var m struct {
+		LockLockable struct {
+			LockedRecord struct {
+				Locked bool
+			}
+		} `graphql:"lockLockable(input: $input)"`
+	}
+	input := githubv4.LockLockableInput{LockableID: githubv4.ID(id)}
+	if err := c.mutate(ctx, &m, input); err != nil {
+		return fmt.Errorf("could not lock discussion: %v", err)
+	}
+	return nil
+}
+
+// AddLabel adds the repository's label with the name to the discussion (or any other labelable).
+func (c *Client) AddLabel(ctx context.Context, id, name string) error {
+	var q struct {
+		Repository struct {
+			Label *struct {
+				ID string
+			} `graphql:"label(name: $label)"`
+		} `graphql:"repository(owner: $owner, name: $name)"`
+		RateLimit RateLimit
+	}
+	err := c.query(ctx, &q,
+		map[string]interface{}{
+			"owner": githubv4.String(c.owner),
+			"name":  githubv4.String(c.repo),
+			"label": githubv4.String(name),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("could not get label: %v", err)
+	}
+	c.track(q.RateLimit)
+	if q.Repository.Label == nil {
+		return fmt.Errorf("could not find label %q", name)
+	}
+
+	var m struct {
+		AddLabelsToLabelable struct {
+			ClientMutationID string
+		} `graphql:"addLabelsToLabelable(input: $input)"`
+	}
+	input := githubv4.AddLabelsToLabelableInput{
+		LabelableID: githubv4.ID(id),
+		LabelIDs:    []githubv4.ID{githubv4.ID(q.Repository.Label.ID)},
+	}
+	if err := c.mutate(ctx, &m, input); err != nil {
+		return fmt.Errorf("could not add label: %v", err)
+	}
+	return nil
+}
+
+// DiscussionCloseReason is the reason for closing a discussion: DUPLICATE, OUTDATED or RESOLVED.
+// It is missing in githubv4.
+type DiscussionCloseReason string
+
+// CloseDiscussionInput is the input of the closeDiscussion mutation, which is missing in githubv4.
+type CloseDiscussionInput struct {
+	DiscussionID githubv4.ID            `json:"discussionId"`
+	Reason       *DiscussionCloseReason `json:"reason,omitempty"`
+}
+
+func (c *Client) CloseDiscussion(ctx context.Context, id string, reason DiscussionCloseReason) error {
+	var m struct {
+		CloseDiscussion struct {
+			Discussion struct {
+				ID string
+			}
+		} `graphql:"closeDiscussion(input: $input)"`
+	}
+	input := CloseDiscussionInput{DiscussionID: githubv4.ID(id)}
+	if reason != "" {
+		input.Reason = &reason
+	}
+	if err := c.mutate(ctx, &m, input); err != nil {
+		return fmt.Errorf("could not close discussion: %v", err)
+	}
+	return nil
+}
+
+// query retries on transient errors and rate limits.
+func (c *Client) query(ctx context.Context, q interface{}, variables map[string]interface{}) error {
+	return c.retry(ctx, true, func() error {
+		return c.gql.Query(ctx, q, variables)
+	})
+}
+
+// mutate is paced and only retried if GitHub has rejected it due to rate limits, since mutations are not idempotent.
+func (c *Client) mutate(ctx context.Context, m interface{}, input githubv4.Input) error {
+	return c.retry(ctx, false, func() error {
+		if err := c.pace(ctx); err != nil {
+			return err
+		}
+		return c.gql.Mutate(ctx, m, input, nil)
+	})
+}
@@ -0,0 +1,191 @@
+package github_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/github"
+)
+
+type redirectTransport struct {
+	srv *httptest.Server
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	u, _ := url.Parse(t.srv.URL)
+	req.URL.Scheme, req.URL.Host = u.Scheme, u.Host
+	return t.srv.Client().Transport.RoundTrip(req)
+}
+
+// redirect returns a client that sends all requests to the test server.
+func redirect(srv *httptest.Server) *http.Client {
+	return &http.Client{Transport: redirectTransport{srv: srv}}
+}
+
+func TestClientRetries(t *testing.T) {
+	testCases := []struct {
+		name         string
+		status       int
+		create       bool
+		wantRequests int
+		wantErr      bool
+	}{
+		{name: "query retried when rate limited", status: http.StatusTooManyRequests, wantRequests: 2},
+		{name: "mutation retried when rate limited", status: http.StatusTooManyRequests, create: true, wantRequests: 3},
+		{name: "mutation not retried on bad gateway", status: http.StatusBadGateway, create: true, wantRequests: 2, wantErr: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			requests := 0
+			failed := false
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+				// the first request of a creation is a query which always succeeds:
+				if !failed && (!tc.create || requests > 1) {
+					failed = true
+					w.Header().Set("Retry-After", "0")
+					w.WriteHeader(tc.status)
+					return
+				}
+				w.Write([]byte(`{"data": {}}`))
+			}))
+			defer srv.Close()
+
+			client := github.New(redirect(srv), "hugo-mods", "hugo-mods.github.io").
+				WithMutationInterval(0)
+			var err error
+			if tc.create {
+				_, err = client.CreateDiscussion(context.Background(), "C1", "Title", "Body")
+			} else {
+				_, err = client.Categories(context.Background())
+			}
+			if (err != nil) != tc.wantErr {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if requests != tc.wantRequests {
+				t.Errorf("want %d requests, got %d", tc.wantRequests, requests)
+			}
+		})
+	}
+}
+
+func TestClientPreflight(t *testing.T) {
+	testCases := []struct {
+		name         string
+		scopes       string
+		write        bool
+		wantProblems int
+	}{
+		{name: "read only reports disabled discussions", scopes: "read:discussion", wantProblems: 1},
+		{name: "public_repo is enough to write", scopes: "read:discussion, public_repo", write: true, wantProblems: 2},
+		{name: "write:discussion is not enough to write", scopes: "read:discussion, write:discussion", write: true, wantProblems: 3},
+		{name: "empty scopes of fine-grained tokens are not checked", scopes: "", write: true, wantProblems: 2},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header()["X-Oauth-Scopes"] = []string{tc.scopes}
+				w.Write([]byte(`{"data": {"repository": {
+					"id": "R1",
+					"nameWithOwner": "hugo-mods/hugo-mods.github.io",
+					"hasDiscussionsEnabled": false,
+					"viewerPermission": "READ"
+				}}}`))
+			}))
+			defer srv.Close()
+			client := github.New(redirect(srv), "hugo-mods", "hugo-mods.github.io")
+
+			err := client.Preflight(context.Background(), tc.write)
+			var pe *github.PreflightError
+			if !errors.As(err, &pe) || len(pe.Problems) != tc.wantProblems {
+				t.Fatalf("want %d problems, got: %v", tc.wantProblems, err)
+			}
+		})
+	}
+}
+
+func TestClientIssuesPaginatesComments(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			w.Write([]byte(`{"data": {"repository": {"issues": {
+				"nodes": [{"id": "I1", "number": 1, "comments": {
+					"nodes": [{"id": "IC1"}],
+					"totalCount": 2,
+					"pageInfo": {"endCursor": "c1", "hasNextPage": true}
+				}}],
+				"pageInfo": {"hasNextPage": false}
+			}}}}`))
+			return
+		}
+		w.Write([]byte(`{"data": {"node": {"comments": {
+			"nodes": [{"id": "IC2"}],
+			"totalCount": 2,
+			"pageInfo": {"endCursor": "c2", "hasNextPage": false}
+		}}}}`))
+	}))
+	defer srv.Close()
+	client := github.New(redirect(srv), "hugo-mods", "hugo-mods.github.io")
+
+	issues, err := client.Issues(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(issues) != 1 || len(issues[0].Comments.Nodes) != 2 || issues[0].Comments.Nodes[1].ID != "IC2" {
+		t.Errorf("want issue with both comments, got %+v", issues)
+	}
+}
+
+func TestClientRetriesRateLimitedQuery(t *testing.T) {
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests == 1 {
+			// GitHub reports the exceeded primary rate limit as GraphQL error with status 200:
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10))
+			w.Write([]byte(`{"data": null, "errors": [{"type": "RATE_LIMITED", "message": "API rate limit exceeded"}]}`))
+			return
+		}
+		w.Write([]byte(`{"data": {}}`))
+	}))
+	defer srv.Close()
+	var waits []time.Duration
+	client := github.New(redirect(srv), "hugo-mods", "hugo-mods.github.io").
+		WithRetryHook(func(attempt, attempts int, wait time.Duration, err error) { waits = append(waits, wait) })
+
+	if _, err := client.Categories(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if requests != 2 || len(waits) != 1 {
+		t.Errorf("want one retry, got %d requests and waits %v", requests, waits)
+	}
+}
+
+func TestClientAwaitsRateLimitReset(t *testing.T) {
+	resetAt := time.Now().Add(time.Second).UTC().Format(time.RFC3339Nano)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data": {"repository": {"discussions": {"nodes": []}}, "rateLimit": {"cost": 1, "remaining": 2, "resetAt": "` + resetAt + `"}}}`))
+	}))
+	defer srv.Close()
+	var waited []github.RateLimit
+	client := github.New(redirect(srv), "hugo-mods", "hugo-mods.github.io").
+		WithRateLimitHook(func(wait time.Duration, rl github.RateLimit) { waited = append(waited, rl) })
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.Discussions(context.Background(), "C1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(waited) != 1 || waited[0].Remaining != 2 {
+		t.Errorf("want to wait once before the second query, got %v", waited)
+	}
+}
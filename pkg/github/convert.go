@@ -0,0 +1,256 @@
+package github
+
+import (
+	"strings"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+	"github.com/hugo-mods/discussions-bridge/pkg/sanitize"
+)
+
+// htmlPolicy is used to sanitise the HTML rendered by GitHub before it gets exported.
+var htmlPolicy = sanitize.DefaultPolicy()
+
+// ToModelDiscussions converts the GitHub discussion to an independent Discussion model.
+// For compactness, it converts only one level of comments, e.g.:
+//
+//	Discussion
+//	├── Comment #1
+//	├── Comment #2
+//
+// Note that GitHub also supports replies for comments:
+//
+//	Discussion
+//	├── Comment #1
+//	├── Comment #2
+//	│   ├── Reply #1
+//
+// The exact content will not be included, only the number of replies.
+func ToModelDiscussions(ghds []Discussion, opts model.Options) []model.Discussion {
+	ds := make([]model.Discussion, len(ghds))
+	for i := range ghds {
+		ds[i] = *ToModelDiscussion(&ghds[i], opts)
+	}
+	return ds
+
+}
+
+func ToModelDiscussion(ghd *Discussion, opts model.Options) *model.Discussion {
+	d := &model.Discussion{
+		Title: ghd.Title,
+		Message: model.Message{
+			ID:             ghd.ID,
+			Number:         ghd.Number,
+			URL:            ghd.URL,
+			CreatedAt:      ghd.CreatedAt,
+			UpdatedAt:      ghd.UpdatedAt,
+			LastEditedAt:   ghd.LastEditedAt,
+			Edited:         ghd.LastEditedAt != nil,
+			Author:         ToModelAuthor(ghd.Author, ghd.AuthorAssociation),
+			Body:           ghd.Body,
+			BodyMIME:       "text/markdown",
+			BodyHTML:       htmlPolicy.HTML(ghd.BodyHTML),
+			UpvotesCount:   0,
+			Reactions:      ToModelReactions(ghd.Reactions.Nodes),
+			ReactionCounts: ToModelReactionGroups(ghd.ReactionGroups),
+		},
+		Comments:       toModelAnsweredComments(ghd.Comments.Nodes, ghd.Answer, opts),
+		Answerable:     ghd.Category.IsAnswerable,
+		Answered:       ghd.IsAnswered,
+		AnswerChosenAt: ghd.AnswerChosenAt,
+		Locked:         ghd.Locked,
+		Closed:         ghd.Closed,
+		Poll:           ToModelPoll(ghd.Poll),
+	}
+	d.UpdateAnswered()
+	return d
+}
+
+func ToModelPoll(ghp *Poll) *model.Poll {
+	if ghp == nil {
+		return nil
+	}
+	options := make([]model.PollOption, len(ghp.Options.Nodes))
+	for i, o := range ghp.Options.Nodes {
+		options[i] = model.PollOption{Option: o.Option, VotesCount: o.TotalVoteCount}
+	}
+	return &model.Poll{
+		Question:   ghp.Question,
+		Options:    options,
+		VotesCount: ghp.TotalVoteCount,
+	}
+}
+
+// ghostLogin is the login GitHub uses for deleted accounts.
+const ghostLogin = "ghost"
+
+// ToModelAuthor converts the author and its association to the repository.
+// Deleted accounts are reported without an author by GitHub and are mapped to its "ghost" user.
+func ToModelAuthor(gha Author, association string) model.Author {
+	ghost := gha.Login == "" || gha.Login == ghostLogin
+	if ghost {
+		gha.Login = ghostLogin
+	}
+	return model.Author{
+		User:        model.User{Name: gha.Login},
+		FullName:    gha.User.Name,
+		PictureURL:  gha.AvatarURL,
+		ProfileURL:  gha.URL,
+		Bot:         gha.Typename == "Bot",
+		Ghost:       ghost,
+		Association: ToModelAssociation(association),
+	}
+}
+
+// associations maps GitHub's comment author associations to roles.
+var associations = map[string]model.Association{
+	"OWNER":                  model.AssociationOwner,
+	"MEMBER":                 model.AssociationMember,
+	"COLLABORATOR":           model.AssociationCollaborator,
+	"CONTRIBUTOR":            model.AssociationContributor,
+	"FIRST_TIME_CONTRIBUTOR": model.AssociationFirstTimer,
+	"FIRST_TIMER":            model.AssociationFirstTimer,
+}
+
+func ToModelAssociation(association string) model.Association {
+	a, ok := associations[strings.ToUpper(association)]
+	if !ok {
+		return model.AssociationNone
+	}
+	return a
+}
+
+// ToModelComments converts the comments. Hidden comments are excluded unless placeholders are enabled.
+func ToModelComments(ghcs []Comment, opts model.Options) []model.Comment {
+	comments := make([]model.Comment, 0, len(ghcs))
+	for i := range ghcs {
+		c := ToModelComment(ghcs[i])
+		if c.Hidden && !opts.HiddenPlaceholders {
+			continue
+		}
+		comments = append(comments, c)
+	}
+	return comments
+}
+
+// toModelAnsweredComments converts the comments and pins the answer (if any) as first comment,
+// even if it has not been part of the fetched comments.
+func toModelAnsweredComments(ghcs []Comment, answer *Comment, opts model.Options) []model.Comment {
+	comments := ToModelComments(ghcs, opts)
+	if answer == nil {
+		return comments
+	}
+	pinnedAnswer := ToModelComments([]Comment{*answer}, opts)
+	if len(pinnedAnswer) == 0 {
+		return comments
+	}
+	pinned := make([]model.Comment, 0, len(comments)+1)
+	pinned = append(pinned, pinnedAnswer[0])
+	for _, c := range comments {
+		if c.ID != answer.ID {
+			pinned = append(pinned, c)
+		}
+	}
+	return pinned
+}
+
+// ToModelComment converts the comment. Minimized and deleted comments are converted
+// to hidden placeholders that only keep the comment's identity and the reason why it is hidden.
+func ToModelComment(ghc Comment) model.Comment {
+	if reason := hiddenReason(ghc); reason != "" {
+		return model.Comment{
+			Message: model.Message{
+				ID:        ghc.ID,
+				Number:    ghc.DatabaseID,
+				URL:       ghc.URL,
+				CreatedAt: ghc.CreatedAt,
+				UpdatedAt: ghc.UpdatedAt,
+			},
+			Hidden:        true,
+			HiddenReason:  reason,
+			CommentsCount: ghc.Replies.TotalCount,
+		}
+	}
+	return model.Comment{
+		Message: model.Message{
+			ID:             ghc.ID,
+			Number:         ghc.DatabaseID,
+			URL:            ghc.URL,
+			CreatedAt:      ghc.CreatedAt,
+			UpdatedAt:      ghc.UpdatedAt,
+			LastEditedAt:   ghc.LastEditedAt,
+			Edited:         ghc.LastEditedAt != nil,
+			Author:         ToModelAuthor(ghc.Author, ghc.AuthorAssociation),
+			Body:           ghc.Body,
+			BodyMIME:       "text/markdown",
+			BodyHTML:       htmlPolicy.HTML(ghc.BodyHTML),
+			UpvotesCount:   ghc.UpvoteCount,
+			Reactions:      ToModelReactions(ghc.Reactions.Nodes),
+			ReactionCounts: ToModelReactionGroups(ghc.ReactionGroups),
+		},
+		Answer:        ghc.IsAnswer,
+		CommentsCount: ghc.Replies.TotalCount,
+	}
+}
+
+func hiddenReason(ghc Comment) string {
+	switch {
+	case ghc.DeletedAt != nil:
+		return "deleted"
+	case ghc.IsMinimized && ghc.MinimizedReason != "":
+		return strings.ToLower(ghc.MinimizedReason)
+	case ghc.IsMinimized:
+		return "minimized"
+	default:
+		return ""
+	}
+}
+
+func ToModelReactions(ghrs []Reaction) model.Reactions {
+	reactions := make(model.Reactions, len(ghrs))
+	for _, ghr := range ghrs {
+		r := ToModelReaction(ghr)
+		reactions[r] = append(reactions[r], model.User{Name: ghr.User.Login})
+	}
+	return reactions
+}
+
+// reactionContents maps GitHub's reaction contents to emoji codes.
+var reactionContents = map[string]model.EmojiCode{
+	"THUMBS_UP":   model.ThumbsUp,
+	"THUMBS_DOWN": model.ThumbsDown,
+	"LAUGH":       model.Smile,
+	"HOORAY":      model.Party,
+	"CONFUSED":    model.Confused,
+	"HEART":       model.Heart,
+	"ROCKET":      model.Rocket,
+	"EYES":        model.Eyes,
+}
+
+func ToModelReaction(ghr Reaction) model.EmojiCode {
+	return toModelEmoji(ghr.Content)
+}
+
+// ToModelReactionGroups converts the reaction groups to counts, skipping emojis without any reactions.
+func ToModelReactionGroups(ghrgs []ReactionGroup) []model.ReactionCount {
+	counts := make([]model.ReactionCount, 0, len(ghrgs))
+	for _, ghrg := range ghrgs {
+		if ghrg.Reactors.TotalCount == 0 {
+			continue
+		}
+		code := toModelEmoji(ghrg.Content)
+		counts = append(counts, model.ReactionCount{
+			Emoji: code,
+			Char:  code.Char(),
+			Count: ghrg.Reactors.TotalCount,
+		})
+	}
+	return counts
+}
+
+func toModelEmoji(content string) model.EmojiCode {
+	code, ok := reactionContents[strings.ToUpper(content)]
+	if !ok {
+		return model.ThoughtBalloon
+	}
+	return code
+}
@@ -0,0 +1,134 @@
+package github_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/github"
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+)
+
+func TestToModelReaction(t *testing.T) {
+	testCases := []struct {
+		content string
+		want    model.EmojiCode
+	}{
+		{content: "THUMBS_UP", want: model.ThumbsUp},
+		{content: "THUMBS_DOWN", want: model.ThumbsDown},
+		{content: "LAUGH", want: model.Smile},
+		{content: "HOORAY", want: model.Party},
+		{content: "CONFUSED", want: model.Confused},
+		{content: "HEART", want: model.Heart},
+		{content: "ROCKET", want: model.Rocket},
+		{content: "EYES", want: model.Eyes},
+		{content: "rocket", want: model.Rocket},
+		{content: "UNKNOWN", want: model.ThoughtBalloon},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.content, func(t *testing.T) {
+			got := github.ToModelReaction(github.Reaction{Content: tc.content})
+			if got != tc.want {
+				t.Errorf("unexpected emoji code: want=%v got=%v", tc.want, got)
+			}
+			if got.Char() == "" {
+				t.Errorf("missing Unicode character for %v", got)
+			}
+		})
+	}
+}
+
+func TestToModelReactionGroups(t *testing.T) {
+	group := func(content string, n int) github.ReactionGroup {
+		g := github.ReactionGroup{Content: content}
+		g.Reactors.TotalCount = n
+		return g
+	}
+	got := github.ToModelReactionGroups([]github.ReactionGroup{
+		group("THUMBS_UP", 3),
+		group("THUMBS_DOWN", 0),
+		group("LAUGH", 1),
+	})
+	want := []model.ReactionCount{
+		{Emoji: model.ThumbsUp, Char: "👍", Count: 3},
+		{Emoji: model.Smile, Char: "😄", Count: 1},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("unexpected result:\n  want=%v\n   got=%v", want, got)
+	}
+}
+
+func TestToModelDiscussionPinsAnswer(t *testing.T) {
+	ghd := github.Discussion{IsAnswered: true}
+	ghd.Comments.Nodes = []github.Comment{
+		{ID: "C1"},
+		{ID: "C2", IsAnswer: true},
+		{ID: "C3"},
+	}
+	ghd.Answer = &ghd.Comments.Nodes[1]
+
+	d := github.ToModelDiscussion(&ghd, model.Options{})
+	if !d.Answered {
+		t.Error("want discussion to be answered")
+	}
+	var got []string
+	for _, c := range d.Comments {
+		got = append(got, c.ID)
+	}
+	want := []string{"C2", "C1", "C3"}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("unexpected comment order:\n  want=%v\n   got=%v", want, got)
+	}
+	if !d.Comments[0].Answer {
+		t.Error("want first comment to be the answer")
+	}
+
+	ghd.Answer.IsMinimized = true
+	d = github.ToModelDiscussion(&ghd, model.Options{HiddenPlaceholders: true})
+	if d.Answered {
+		t.Error("want discussion with hidden answer not to be answered")
+	}
+}
+
+func TestToModelCommentsHidden(t *testing.T) {
+	ghcs := []github.Comment{
+		{ID: "C1", Body: "Hello"},
+		{ID: "C2", Body: "Buy now!", IsMinimized: true, MinimizedReason: "SPAM"},
+	}
+
+	if got := github.ToModelComments(ghcs, model.Options{}); len(got) != 1 || got[0].ID != "C1" {
+		t.Errorf("want hidden comment to be excluded, got %v", got)
+	}
+
+	got := github.ToModelComments(ghcs, model.Options{HiddenPlaceholders: true})
+	if len(got) != 2 {
+		t.Fatalf("want hidden comment as placeholder, got %v", got)
+	}
+	if !got[1].Hidden || got[1].HiddenReason != "spam" || got[1].Body != "" {
+		t.Errorf("unexpected placeholder: %+v", got[1])
+	}
+}
+
+func TestToModelCommentsAuthorAndReactions(t *testing.T) {
+	ghcs := []github.Comment{{
+		ID:     "C1",
+		Author: github.Author{Login: "alice", AvatarURL: "https://avatars.githubusercontent.com/u/1"},
+		Body:   "Thanks @bob",
+	}}
+	ghcs[0].Reactions.Nodes = []github.Reaction{
+		{User: github.Author{Login: "bob"}, Content: "HEART"},
+		{User: github.Author{Login: "carol"}, Content: "HEART"},
+	}
+
+	got := github.ToModelComments(ghcs, model.Options{})
+	if len(got) != 1 {
+		t.Fatalf("want one comment, got %v", got)
+	}
+	if a := got[0].Author; a.Name != "alice" || a.PictureURL != "https://avatars.githubusercontent.com/u/1" {
+		t.Errorf("unexpected author: %+v", a)
+	}
+	want := []model.User{{Name: "bob"}, {Name: "carol"}}
+	if users := got[0].Reactions[model.Heart]; !reflect.DeepEqual(want, users) {
+		t.Errorf("unexpected reaction users:\n  want=%v\n   got=%v", want, users)
+	}
+}
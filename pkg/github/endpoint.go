@@ -0,0 +1,54 @@
+package github
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const (
+	// DefaultGraphQLURL is the GraphQL API of github.com.
+	DefaultGraphQLURL = "https://api.github.com/graphql"
+	// DefaultAPIURL is the REST API of github.com.
+	DefaultAPIURL = "https://api.github.com"
+	// DefaultServerURL is the web interface of github.com.
+	DefaultServerURL = "https://github.com"
+)
+
+// ValidateEndpoint checks that the GraphQL endpoint is an absolute HTTP(S) URL ending with "/graphql".
+func ValidateEndpoint(graphqlURL string) error {
+	u, err := url.Parse(graphqlURL)
+	if err != nil {
+		return err
+	}
+	if u.Scheme != "https" && u.Scheme != "http" {
+		return fmt.Errorf("scheme must be https or http, got %q", u.Scheme)
+	}
+	if u.Host == "" {
+		return fmt.Errorf("host must not be empty")
+	}
+	if !strings.HasSuffix(strings.TrimSuffix(u.Path, "/"), "/graphql") {
+		return fmt.Errorf("path must end with /graphql, got %q", u.Path)
+	}
+	return nil
+}
+
+// ServerURL derives the web interface's URL from the GraphQL endpoint.
+// GitHub Enterprise Server serves its API at "https://HOST/api/graphql".
+func ServerURL(graphqlURL string) string {
+	u, err := url.Parse(graphqlURL)
+	if err != nil || u.Host == "api.github.com" {
+		return DefaultServerURL
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// APIURL derives the REST API's URL from the GraphQL endpoint.
+// GitHub Enterprise Server serves its REST API at "https://HOST/api/v3".
+func APIURL(graphqlURL string) string {
+	u, err := url.Parse(graphqlURL)
+	if err != nil || u.Host == "api.github.com" {
+		return DefaultAPIURL
+	}
+	return u.Scheme + "://" + u.Host + "/api/v3"
+}
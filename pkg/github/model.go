@@ -1,39 +1,72 @@
 package github
 
+import "time"
+
 type Discussion struct {
-	URL         string
-	Title       string
-	Body        string
-	Author      Author
-	Locked      bool
-	UpvoteCount int
-	Comments    struct {
+	ID                string
+	Number            int
+	URL               string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	LastEditedAt      *time.Time
+	AnswerChosenAt    *time.Time
+	Title             string
+	Body              string
+	BodyHTML          string `graphql:"bodyHTML @include(if: $withBodyHTML)"`
+	Author            Author
+	AuthorAssociation string
+	Locked            bool
+	Closed            bool
+	UpvoteCount       int
+	IsAnswered        bool
+	Answer            *Comment
+	Category          struct {
+		IsAnswerable bool
+	}
+	Poll     *Poll
+	Comments struct {
 		Nodes      []Comment
 		TotalCount int
 	} `graphql:"comments(first: $firstComments)"`
 	Reactions struct {
 		Nodes      []Reaction
 		TotalCount int
-	} `graphql:"reactions(first: $firstReactions)"`
+	} `graphql:"reactions(first: $firstReactions) @include(if: $withReactionUsers)"`
+	ReactionGroups []ReactionGroup
 }
 
 type Author struct {
 	Login     string
 	URL       string
 	AvatarURL string `graphql:"avatarUrl(size: 64)"`
+	Typename  string `graphql:"__typename"`
+	User      struct {
+		Name string
+	} `graphql:"... on User"`
 }
 
 type Comment struct {
+	ID                string
+	DatabaseID        int
 	URL               string
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+	LastEditedAt      *time.Time
 	Author            Author
 	AuthorAssociation string
 	Body              string
+	BodyHTML          string `graphql:"bodyHTML @include(if: $withBodyHTML)"`
 	UpvoteCount       int
+	IsAnswer          bool
+	IsMinimized       bool
+	MinimizedReason   string
+	DeletedAt         *time.Time
 	Reactions         struct {
 		Nodes      []Reaction
 		TotalCount int
-	} `graphql:"reactions(first: $firstReactions)"`
-	Replies struct {
+	} `graphql:"reactions(first: $firstReactions) @include(if: $withReactionUsers)"`
+	ReactionGroups []ReactionGroup
+	Replies        struct {
 		TotalCount int
 	}
 }
@@ -43,6 +76,28 @@ type Reaction struct {
 	Content string
 }
 
+type Poll struct {
+	Question       string
+	TotalVoteCount int
+	// GitHub allows up to 8 options per poll.
+	Options struct {
+		Nodes []PollOption
+	} `graphql:"options(first: 8)"`
+}
+
+type PollOption struct {
+	Option         string
+	TotalVoteCount int
+}
+
+// ReactionGroup summarizes all reactions with the same content.
+type ReactionGroup struct {
+	Content  string
+	Reactors struct {
+		TotalCount int
+	}
+}
+
 type Discussions []Discussion
 
 func (ds Discussions) ByFilter(filter func(c Discussion) bool, n int) Discussions {
@@ -56,10 +111,30 @@ func (ds Discussions) ByFilter(filter func(c Discussion) bool, n int) Discussion
 	return res
 }
 
+type Repository struct {
+	ID                    string
+	NameWithOwner         string
+	HasDiscussionsEnabled bool
+	// ViewerPermission is the permission of the authenticated user, i.e. ADMIN, MAINTAIN, WRITE, TRIAGE or READ.
+	ViewerPermission string
+}
+
+// CanWrite reports whether the authenticated user can create content in the repository.
+func (r *Repository) CanWrite() bool {
+	switch r.ViewerPermission {
+	case "ADMIN", "MAINTAIN", "WRITE":
+		return true
+	default:
+		return false
+	}
+}
+
 type Category struct {
 	ID    string
 	Emoji string
 	Name  string
+	// IsAnswerable is true for Q&A-format categories in which a comment can be marked as answer.
+	IsAnswerable bool
 	// Description string
 }
 
@@ -88,3 +163,34 @@ func (cs Categories) First() *Category {
 		return &cs[0]
 	}
 }
+
+// Issue is a repository issue, e.g. one created by utterances for a page.
+type Issue struct {
+	ID        string
+	Number    int
+	URL       string
+	CreatedAt time.Time
+	Title     string
+	Body      string
+	Author    Author
+	Closed    bool
+	// Comments are the first 100 comments. Client.Issues fetches the others.
+	Comments IssueComments `graphql:"comments(first: 100)"`
+}
+
+type IssueComments struct {
+	Nodes      []IssueComment
+	TotalCount int
+	PageInfo   struct {
+		EndCursor   string
+		HasNextPage bool
+	}
+}
+
+type IssueComment struct {
+	ID        string
+	URL       string
+	CreatedAt time.Time
+	Author    Author
+	Body      string
+}
@@ -0,0 +1,53 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PreflightError lists everything that prevents the bridge from working with the repository.
+type PreflightError struct {
+	Problems []string
+}
+
+func (e *PreflightError) Error() string {
+	return "  - " + strings.Join(e.Problems, "\n  - ")
+}
+
+// Preflight checks up front whether discussions are available and whether the token is allowed to read
+// and, if write is true, create them. Capabilities that cannot be determined are not reported as problems.
+func (c *Client) Preflight(ctx context.Context, write bool) error {
+	repository, err := c.Repository(ctx)
+	if err != nil {
+		return &PreflightError{Problems: []string{
+			fmt.Sprintf("could not access repository %s/%s via %s, please check the endpoint, that the repository exists and that the token can read it: %v", c.owner, c.repo, c.endpoint, err),
+		}}
+	}
+
+	var problems []string
+	if !repository.HasDiscussionsEnabled {
+		problems = append(problems, fmt.Sprintf("discussions are disabled for %s, please enable them in the repository's settings", repository.NameWithOwner))
+	}
+	if write && repository.ViewerPermission != "" && !repository.CanWrite() {
+		problems = append(problems, fmt.Sprintf("token has %s permission on %s, but WRITE is needed to create discussions", repository.ViewerPermission, repository.NameWithOwner))
+	}
+	if scopes := c.transport.tokenScopes(); write && scopes != nil && !hasWriteScopes(scopes) {
+		problems = append(problems, fmt.Sprintf("token has scopes %v, but needs \"repo\" or \"public_repo\" to create discussions", scopes))
+	}
+	if len(problems) > 0 {
+		return &PreflightError{Problems: problems}
+	}
+	return nil
+}
+
+// hasWriteScopes checks the scopes of a classic personal access token.
+// Note that "write:discussion" is about team discussions, not repository discussions.
+func hasWriteScopes(scopes []string) bool {
+	for _, s := range scopes {
+		if s == "repo" || s == "public_repo" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+	"github.com/hugo-mods/discussions-bridge/pkg/provider"
+)
+
+var (
+	_ provider.DiscussionProvider = (*Provider)(nil)
+	_ provider.Commenter          = (*Provider)(nil)
+	_ provider.Locker             = (*Provider)(nil)
+	_ provider.Closer             = (*Provider)(nil)
+	_ provider.Labeler            = (*Provider)(nil)
+)
+
+// Provider serves GitHub Discussions as independent models.
+type Provider struct {
+	client *Client
+	opts   model.Options
+}
+
+func NewProvider(client *Client, opts model.Options) *Provider {
+	return &Provider{client: client, opts: opts}
+}
+
+// Client returns the underlying client, e.g. for preflight checks.
+func (p *Provider) Client() *Client {
+	return p.client
+}
+
+func (p *Provider) Categories(ctx context.Context) (model.Categories, error) {
+	ghcs, err := p.client.Categories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	categories := make(model.Categories, len(ghcs))
+	for i, ghc := range ghcs {
+		categories[i] = model.Category{ID: ghc.ID, Name: ghc.Name, Answerable: ghc.IsAnswerable}
+	}
+	return categories, nil
+}
+
+func (p *Provider) Discussions(ctx context.Context, categoryID string) ([]model.Discussion, error) {
+	ghds, err := p.client.Discussions(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	return ToModelDiscussions(ghds, p.opts), nil
+}
+
+func (p *Provider) Discussion(ctx context.Context, id string) (*model.Discussion, error) {
+	ghd, err := p.client.Discussion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return ToModelDiscussion(ghd, p.opts), nil
+}
+
+func (p *Provider) CreateDiscussion(ctx context.Context, categoryID, title, body string) (string, error) {
+	return p.client.CreateDiscussion(ctx, categoryID, title, body)
+}
+
+func (p *Provider) UpdateDiscussion(ctx context.Context, id, title, body string) error {
+	return p.client.UpdateDiscussion(ctx, id, title, body)
+}
+
+func (p *Provider) AddComment(ctx context.Context, id, body string) error {
+	_, err := p.client.AddDiscussionComment(ctx, id, body)
+	return err
+}
+
+func (p *Provider) LockDiscussion(ctx context.Context, id string) error {
+	return p.client.LockDiscussion(ctx, id)
+}
+
+func (p *Provider) CloseDiscussion(ctx context.Context, id string, reason provider.CloseReason) error {
+	return p.client.CloseDiscussion(ctx, id, DiscussionCloseReason(strings.ToUpper(string(reason))))
+}
+
+func (p *Provider) AddLabel(ctx context.Context, id, label string) error {
+	return p.client.AddLabel(ctx, id, label)
+}
+
+// Stats reports the consumed GraphQL query cost and the remaining rate limit.
+func (p *Provider) Stats() string {
+	cost, rateLimit := p.client.Cost()
+	return fmt.Sprintf("consumed GraphQL query cost of %d points. %d points remaining until %s.", cost, rateLimit.Remaining, rateLimit.ResetAt)
+}
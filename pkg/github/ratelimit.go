@@ -0,0 +1,260 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit is GraphQL's rate limit state as reported by queries.
+type RateLimit struct {
+	Cost      int
+	Remaining int
+	ResetAt   time.Time
+}
+
+// rateLimitTransport remembers the status and rate limit headers of the last response,
+// since the GraphQL client does not expose them in its errors.
+type rateLimitTransport struct {
+	base http.RoundTripper
+
+	mu         sync.Mutex
+	status     int
+	remaining  int
+	reset      time.Time
+	retryAfter time.Duration
+	// graphQLLimited is true if the primary rate limit has been exceeded, which GraphQL reports with status 200.
+	graphQLLimited bool
+	// scopes are the OAuth scopes of a classic token. Nil if unknown, e.g. for fine-grained or installation tokens.
+	scopes []string
+}
+
+func newRateLimitTransport(base http.RoundTripper) *rateLimitTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rateLimitTransport{base: base, remaining: -1}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.status, t.retryAfter, t.graphQLLimited = 0, 0, false
+	if err != nil {
+		return resp, err
+	}
+	t.status = resp.StatusCode
+	if resp.StatusCode == http.StatusOK {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+		t.graphQLLimited = rateLimitedError(body)
+	}
+	if remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); err == nil {
+		t.remaining = remaining
+	}
+	if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		t.reset = time.Unix(reset, 0)
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		t.retryAfter = time.Duration(secs) * time.Second
+	}
+	// Fine-grained and installation tokens send an empty header, their scopes stay unknown:
+	if values := resp.Header.Values("X-OAuth-Scopes"); len(values) > 0 {
+		t.scopes = nil
+		for _, scope := range strings.Split(strings.Join(values, ","), ",") {
+			if scope = strings.TrimSpace(scope); scope != "" {
+				t.scopes = append(t.scopes, scope)
+			}
+		}
+	}
+	return resp, err
+}
+
+// rateLimitedError reports whether the GraphQL response contains an error of type RATE_LIMITED.
+func rateLimitedError(body []byte) bool {
+	if !bytes.Contains(body, []byte("RATE_LIMITED")) {
+		return false
+	}
+	var resp struct {
+		Errors []struct {
+			Type string
+		}
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return false
+	}
+	for _, e := range resp.Errors {
+		if e.Type == "RATE_LIMITED" {
+			return true
+		}
+	}
+	return false
+}
+
+// rateLimited reports whether the last request has been rejected due to a (primary or secondary) rate limit
+// and how long to wait before trying again.
+func (t *rateLimitTransport) rateLimited() (time.Duration, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.graphQLLimited {
+		switch wait := time.Until(t.reset); {
+		case t.reset.IsZero():
+			return maxBackoff, true
+		case wait > 0:
+			return wait, true
+		default:
+			return minBackoff, true
+		}
+	}
+	if t.status != http.StatusForbidden && t.status != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if t.retryAfter > 0 {
+		return t.retryAfter, true
+	}
+	if t.remaining == 0 {
+		return time.Until(t.reset), true
+	}
+	return 0, t.status == http.StatusTooManyRequests
+}
+
+func (t *rateLimitTransport) tokenScopes() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.scopes
+}
+
+// transient reports whether the last request failed due to a temporary server or network problem.
+func (t *rateLimitTransport) transient() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch t.status {
+	case 0, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+const (
+	minBackoff = 1 * time.Second
+	maxBackoff = 1 * time.Minute
+	// minRemaining is the number of remaining points below which requests wait until the rate limit resets.
+	minRemaining = 10
+)
+
+// retry calls fn until it succeeds, the context is done or the maximum number of retries is exhausted.
+// Transient errors are only retried if the operation is idempotent, whereas rate limited requests
+// have been rejected by GitHub and can always be retried.
+func (c *Client) retry(ctx context.Context, idempotent bool, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := c.awaitRateLimit(ctx); err != nil {
+			return err
+		}
+		err := fn()
+		if err == nil || ctx.Err() != nil || attempt >= c.maxRetries {
+			return err
+		}
+		wait, limited := c.transport.rateLimited()
+		if !limited && strings.Contains(err.Error(), "secondary rate limit") {
+			// GitHub recommends to wait at least one minute if no further hint is given.
+			wait, limited = maxBackoff, true
+		}
+		if !limited {
+			if !idempotent || !c.transport.transient() {
+				return err
+			}
+			wait = c.backoff(attempt)
+		}
+		if c.onRetry != nil {
+			c.onRetry(attempt+1, c.maxRetries+1, wait, err)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("%v: %w", err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// awaitRateLimit waits until the rate limit resets if the last known remaining points are running low,
+// i.e. below minRemaining or the cost of the last query, so that requests do not fail in the middle of a run.
+func (c *Client) awaitRateLimit(ctx context.Context) error {
+	rl := c.rateLimit
+	if rl.ResetAt.IsZero() || (rl.Remaining >= minRemaining && rl.Remaining >= rl.Cost) {
+		return nil
+	}
+	wait := time.Until(rl.ResetAt)
+	if wait <= 0 {
+		return nil
+	}
+	if c.onRateLimit != nil {
+		c.onRateLimit(wait, rl)
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("stopped waiting for the rate limit to reset: %w", ctx.Err())
+	case <-timer.C:
+	}
+	// the points are known again with the next query:
+	c.rateLimit.ResetAt = time.Time{}
+	return nil
+}
+
+// backoff grows exponentially with the attempt and is randomized by "equal jitter".
+func (c *Client) backoff(attempt int) time.Duration {
+	d := minBackoff << uint(attempt)
+	if d <= 0 || d > maxBackoff {
+		d = maxBackoff
+	}
+	return d/2 + time.Duration(c.rand.Int63n(int64(d/2)+1))
+}
+
+// pace waits until the minimum interval since the last mutation has passed,
+// as recommended by GitHub for requests that create content.
+func (c *Client) pace(ctx context.Context) error {
+	wait := time.Until(c.lastMutation.Add(c.mutationInterval))
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	c.lastMutation = time.Now()
+	return nil
+}
+
+func (c *Client) track(rl RateLimit) {
+	c.cost += rl.Cost
+	c.rateLimit = rl
+}
+
+// Cost returns the total GraphQL query cost consumed by this client and the last known rate limit.
+func (c *Client) Cost() (int, RateLimit) {
+	return c.cost, c.rateLimit
+}
+
+func newRand() *rand.Rand {
+	return rand.New(rand.NewSource(time.Now().UnixNano()))
+}
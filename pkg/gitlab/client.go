@@ -0,0 +1,235 @@
+// Package gitlab uses the issues of a GitLab project as discussions.
+// A label takes the role of the discussion category and threaded notes become comments with replies.
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/rest"
+)
+
+// DefaultServerURL is the URL of gitlab.com.
+const DefaultServerURL = "https://gitlab.com"
+
+// pageSize is the maximum number of items GitLab returns per page.
+const pageSize = 100
+
+type Client struct {
+	api     *rest.Client
+	project string
+
+	maxDiscussions int
+	maxComments    int
+	awards         bool
+	awardUsers     bool
+}
+
+// New creates a client for the project, e.g. "group/subgroup/project", on the instance at serverURL.
+// The HTTP client is expected to authenticate the requests, e.g. with an access token.
+func New(client *http.Client, serverURL, project string) *Client {
+	return &Client{
+		api:     rest.New(client, strings.TrimSuffix(serverURL, "/")+"/api/v4"),
+		project: project,
+
+		maxComments:    50,
+		maxDiscussions: 100,
+		awards:         true,
+		awardUsers:     true,
+	}
+}
+
+func (c *Client) WithMaxComments(n int) *Client {
+	c.maxComments = n
+	return c
+}
+
+func (c *Client) WithMaxDiscussions(n int) *Client {
+	c.maxDiscussions = n
+	return c
+}
+
+// WithAwards controls whether award emoji are fetched. This takes one request per issue and note.
+func (c *Client) WithAwards(enabled bool) *Client {
+	c.awards = enabled
+	return c
+}
+
+// WithAwardUsers controls whether the users who awarded emoji are exported or only the counts.
+// Counting the award emoji still takes the requests of WithAwards.
+func (c *Client) WithAwardUsers(enabled bool) *Client {
+	c.awardUsers = enabled
+	return c
+}
+
+// Members returns the project's members, including those inherited from its groups.
+func (c *Client) Members(ctx context.Context) ([]Member, error) {
+	var members []Member
+	for page := 1; ; page++ {
+		var ms []Member
+		if err := c.api.Get(ctx, c.projectPath("members", "all"), url.Values{"page": {strconv.Itoa(page)}, "per_page": {strconv.Itoa(pageSize)}}, &ms); err != nil {
+			return nil, fmt.Errorf("could not get members: %w", err)
+		}
+		members = append(members, ms...)
+		if len(ms) < pageSize {
+			return members, nil
+		}
+	}
+}
+
+// Labels returns all labels of the project.
+func (c *Client) Labels(ctx context.Context) ([]Label, error) {
+	var labels []Label
+	for page := 1; ; page++ {
+		var l []Label
+		if err := c.api.Get(ctx, c.projectPath("labels"), url.Values{"page": {strconv.Itoa(page)}, "per_page": {strconv.Itoa(pageSize)}}, &l); err != nil {
+			return nil, fmt.Errorf("could not get labels: %w", err)
+		}
+		labels = append(labels, l...)
+		if len(l) < pageSize {
+			return labels, nil
+		}
+	}
+}
+
+// Issues returns the open and closed issues with the label, most recently updated first.
+func (c *Client) Issues(ctx context.Context, label string) ([]Issue, error) {
+	var issues []Issue
+	for page := 1; len(issues) < c.maxDiscussions; page++ {
+		var is []Issue
+		query := url.Values{
+			"labels":   {label},
+			"order_by": {"updated_at"},
+			"sort":     {"desc"},
+			"page":     {strconv.Itoa(page)},
+			"per_page": {strconv.Itoa(pageSize)},
+		}
+		if err := c.api.Get(ctx, c.projectPath("issues"), query, &is); err != nil {
+			return nil, fmt.Errorf("could not get issues: %w", err)
+		}
+		issues = append(issues, is...)
+		if len(is) < pageSize {
+			break
+		}
+	}
+	if len(issues) > c.maxDiscussions {
+		issues = issues[:c.maxDiscussions]
+	}
+	return issues, nil
+}
+
+func (c *Client) Issue(ctx context.Context, iid int) (*Issue, error) {
+	var issue Issue
+	if err := c.api.Get(ctx, c.projectPath("issues", strconv.Itoa(iid)), nil, &issue); err != nil {
+		return nil, fmt.Errorf("could not get issue #%d: %w", iid, err)
+	}
+	return &issue, nil
+}
+
+// Thread fetches the discussions of the issue and, if enabled, the award emoji.
+func (c *Client) Thread(ctx context.Context, issue Issue) (*Thread, error) {
+	t := &Thread{Issue: issue, NoteAwards: map[int64][]AwardEmoji{}}
+	iid := strconv.Itoa(issue.IID)
+	if issue.NotesCount > 0 && c.maxComments > 0 {
+		query := url.Values{"page": {"1"}, "per_page": {strconv.Itoa(c.maxComments)}}
+		if err := c.api.Get(ctx, c.projectPath("issues", iid, "discussions"), query, &t.Discussions); err != nil {
+			return nil, fmt.Errorf("could not get discussions of issue #%d: %w", issue.IID, err)
+		}
+	}
+	if !c.awards {
+		return t, nil
+	}
+	if err := c.api.Get(ctx, c.projectPath("issues", iid, "award_emoji"), nil, &t.Awards); err != nil {
+		return nil, fmt.Errorf("could not get award emoji of issue #%d: %w", issue.IID, err)
+	}
+	for _, d := range t.Discussions {
+		for _, n := range d.Notes {
+			if n.System || n.Internal || n.Confidential {
+				continue
+			}
+			var awards []AwardEmoji
+			if err := c.api.Get(ctx, c.projectPath("issues", iid, "notes", strconv.FormatInt(n.ID, 10), "award_emoji"), nil, &awards); err != nil {
+				return nil, fmt.Errorf("could not get award emoji of note %d: %w", n.ID, err)
+			}
+			t.NoteAwards[n.ID] = awards
+		}
+	}
+	return t, nil
+}
+
+// CreateIssue creates an issue with the labels and returns it.
+func (c *Client) CreateIssue(ctx context.Context, title, description string, labels ...string) (*Issue, error) {
+	input := struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		Labels      string `json:"labels,omitempty"`
+	}{Title: title, Description: description, Labels: strings.Join(labels, ",")}
+	var issue Issue
+	if err := c.api.Send(ctx, http.MethodPost, c.projectPath("issues"), input, &issue); err != nil {
+		return nil, fmt.Errorf("could not create issue: %w", err)
+	}
+	return &issue, nil
+}
+
+// EditIssue replaces the title and description of the issue.
+func (c *Client) EditIssue(ctx context.Context, iid int, title, description string) error {
+	input := struct {
+		Title       string `json:"title"`
+		Description string `json:"description"`
+	}{Title: title, Description: description}
+	if err := c.api.Send(ctx, http.MethodPut, c.projectPath("issues", strconv.Itoa(iid)), input, nil); err != nil {
+		return fmt.Errorf("could not edit issue #%d: %w", iid, err)
+	}
+	return nil
+}
+
+// CloseIssue sets the issue's state to closed.
+func (c *Client) CloseIssue(ctx context.Context, iid int) error {
+	input := struct {
+		StateEvent string `json:"state_event"`
+	}{StateEvent: "close"}
+	if err := c.api.Send(ctx, http.MethodPut, c.projectPath("issues", strconv.Itoa(iid)), input, nil); err != nil {
+		return fmt.Errorf("could not close issue #%d: %w", iid, err)
+	}
+	return nil
+}
+
+// LockIssue locks the issue's discussion, so that only project members can comment.
+func (c *Client) LockIssue(ctx context.Context, iid int) error {
+	input := struct {
+		DiscussionLocked bool `json:"discussion_locked"`
+	}{DiscussionLocked: true}
+	if err := c.api.Send(ctx, http.MethodPut, c.projectPath("issues", strconv.Itoa(iid)), input, nil); err != nil {
+		return fmt.Errorf("could not lock issue #%d: %w", iid, err)
+	}
+	return nil
+}
+
+// AddLabels adds the labels to the issue. Labels that do not exist yet are created.
+func (c *Client) AddLabels(ctx context.Context, iid int, labels ...string) error {
+	input := struct {
+		AddLabels string `json:"add_labels"`
+	}{AddLabels: strings.Join(labels, ",")}
+	if err := c.api.Send(ctx, http.MethodPut, c.projectPath("issues", strconv.Itoa(iid)), input, nil); err != nil {
+		return fmt.Errorf("could not label issue #%d: %w", iid, err)
+	}
+	return nil
+}
+
+func (c *Client) CreateNote(ctx context.Context, iid int, body string) error {
+	input := struct {
+		Body string `json:"body"`
+	}{Body: body}
+	if err := c.api.Send(ctx, http.MethodPost, c.projectPath("issues", strconv.Itoa(iid), "notes"), input, nil); err != nil {
+		return fmt.Errorf("could not comment on issue #%d: %w", iid, err)
+	}
+	return nil
+}
+
+func (c *Client) projectPath(elems ...string) string {
+	return "/projects/" + url.PathEscape(c.project) + "/" + strings.Join(elems, "/")
+}
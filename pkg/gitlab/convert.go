@@ -0,0 +1,145 @@
+package gitlab
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+)
+
+// ToModelDiscussion converts the issue thread to an independent Discussion model:
+//
+//	Discussion (issue)
+//	├── Comment #1 (first note of a discussion)
+//	│   ├── Reply #1 (following notes)
+//
+// System notes, e.g. about label changes, and internal notes are skipped.
+// Authors are associated with the project by the given associations, e.g. of its members.
+func ToModelDiscussion(t *Thread, associations model.Associations, opts model.Options) *model.Discussion {
+	d := &model.Discussion{
+		Title: t.Issue.Title,
+		Message: model.Message{
+			ID:             strconv.Itoa(t.Issue.IID),
+			Number:         t.Issue.IID,
+			URL:            t.Issue.WebURL,
+			CreatedAt:      t.Issue.CreatedAt,
+			UpdatedAt:      t.Issue.UpdatedAt,
+			Author:         ToModelAuthor(t.Issue.Author, associations),
+			Body:           t.Issue.Description,
+			BodyMIME:       "text/markdown",
+			UpvotesCount:   t.Issue.Upvotes,
+			Reactions:      ToModelReactions(t.Awards),
+			ReactionCounts: ToModelReactionCounts(t.Awards),
+		},
+		Locked: t.Issue.Locked != nil && *t.Issue.Locked,
+		Closed: t.Issue.State == "closed",
+	}
+	d.Comments = make([]model.Comment, 0, len(t.Discussions))
+	for _, gld := range t.Discussions {
+		var comment *model.Comment
+		for _, n := range gld.Notes {
+			if n.System || n.Internal || n.Confidential {
+				continue
+			}
+			c := ToModelComment(n, t.NoteAwards[n.ID], t.Issue.WebURL, associations)
+			if comment == nil {
+				comment = &c
+				continue
+			}
+			comment.Comments = append(comment.Comments, c)
+			comment.CommentsCount++
+		}
+		if comment != nil {
+			d.Comments = append(d.Comments, *comment)
+		}
+	}
+	return d
+}
+
+func ToModelComment(n Note, awards []AwardEmoji, issueURL string, associations model.Associations) model.Comment {
+	var lastEditedAt *time.Time
+	if n.UpdatedAt.After(n.CreatedAt) {
+		updatedAt := n.UpdatedAt
+		lastEditedAt = &updatedAt
+	}
+	return model.Comment{
+		Message: model.Message{
+			ID:             strconv.FormatInt(n.ID, 10),
+			Number:         int(n.ID),
+			URL:            issueURL + "#note_" + strconv.FormatInt(n.ID, 10),
+			CreatedAt:      n.CreatedAt,
+			UpdatedAt:      n.UpdatedAt,
+			LastEditedAt:   lastEditedAt,
+			Edited:         lastEditedAt != nil,
+			Author:         ToModelAuthor(n.Author, associations),
+			Body:           n.Body,
+			BodyMIME:       "text/markdown",
+			Reactions:      ToModelReactions(awards),
+			ReactionCounts: ToModelReactionCounts(awards),
+		},
+	}
+}
+
+// ghostUsername is the username GitLab uses for deleted accounts.
+const ghostUsername = "ghost"
+
+func ToModelAuthor(u User, associations model.Associations) model.Author {
+	return model.Author{
+		User:        model.User{Name: u.Username},
+		FullName:    u.Name,
+		PictureURL:  u.AvatarURL,
+		ProfileURL:  u.WebURL,
+		Bot:         u.Bot,
+		Ghost:       u.Username == ghostUsername,
+		Association: associations.Of(u.Username),
+	}
+}
+
+// awardNames maps GitLab's award emoji names to emoji codes.
+// Other emoji keep their name as code, e.g. ":coffee:", but have no known character.
+var awardNames = map[string]model.EmojiCode{
+	"thumbsup":   model.ThumbsUp,
+	"thumbsdown": model.ThumbsDown,
+	"smile":      model.Smile,
+	"laughing":   model.Smile,
+	"tada":       model.Party,
+	"confused":   model.Confused,
+	"heart":      model.Heart,
+	"rocket":     model.Rocket,
+	"eyes":       model.Eyes,
+	"thinking":   model.Thinking,
+}
+
+func ToModelReaction(a AwardEmoji) model.EmojiCode {
+	code, ok := awardNames[a.Name]
+	if !ok {
+		return model.EmojiCode(":" + a.Name + ":")
+	}
+	return code
+}
+
+func ToModelReactions(as []AwardEmoji) model.Reactions {
+	reactions := make(model.Reactions, len(as))
+	for _, a := range as {
+		code := ToModelReaction(a)
+		reactions[code] = append(reactions[code], model.User{Name: a.User.Username})
+	}
+	return reactions
+}
+
+// ToModelReactionCounts counts the award emoji per emoji in order of their first appearance.
+func ToModelReactionCounts(as []AwardEmoji) []model.ReactionCount {
+	counts := make([]model.ReactionCount, 0, len(as))
+	index := make(map[model.EmojiCode]int, len(as))
+	for _, a := range as {
+		code := ToModelReaction(a)
+		i, ok := index[code]
+		if !ok {
+			i = len(counts)
+			index[code] = i
+			counts = append(counts, model.ReactionCount{Emoji: code, Char: code.Char()})
+		}
+		counts[i].Count++
+	}
+	return counts
+}
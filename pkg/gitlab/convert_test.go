@@ -0,0 +1,61 @@
+package gitlab_test
+
+import (
+	"testing"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/gitlab"
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+)
+
+func TestToModelReaction(t *testing.T) {
+	testCases := []struct {
+		name string
+		want model.EmojiCode
+	}{
+		{name: "thumbsup", want: model.ThumbsUp},
+		{name: "laughing", want: model.Smile},
+		{name: "tada", want: model.Party},
+		{name: "coffee", want: model.EmojiCode(":coffee:")},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := gitlab.ToModelReaction(gitlab.AwardEmoji{Name: tc.name})
+			if got != tc.want {
+				t.Errorf("want %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestToModelDiscussionThreads(t *testing.T) {
+	thread := &gitlab.Thread{
+		Issue: gitlab.Issue{IID: 3, WebURL: "https://gitlab.com/hugo-mods/blog/-/issues/3"},
+		Discussions: []gitlab.Discussion{
+			{Notes: []gitlab.Note{{ID: 1, Body: "added label", System: true}}},
+			{Notes: []gitlab.Note{
+				{ID: 2, Body: "Question?", Author: gitlab.User{Username: "reader"}},
+				{ID: 3, Body: "Answer.", Author: gitlab.User{Username: "hugo-mods"}},
+			}},
+			{IndividualNote: true, Notes: []gitlab.Note{{ID: 4, Body: "Thanks!"}}},
+			{IndividualNote: true, Notes: []gitlab.Note{{ID: 5, Body: "Spam, ignore", Internal: true}}},
+			{IndividualNote: true, Notes: []gitlab.Note{{ID: 6, Body: "Spam, ignore", Confidential: true}}},
+		},
+	}
+
+	d := gitlab.ToModelDiscussion(thread, model.Associations{"hugo-mods": model.AssociationOwner}, model.Options{})
+	if len(d.Comments) != 2 {
+		t.Fatalf("want 2 comments without system and internal notes, got %d", len(d.Comments))
+	}
+	first := d.Comments[0]
+	if first.Body != "Question?" || first.CommentsCount != 1 || len(first.Comments) != 1 {
+		t.Fatalf("want first comment with one reply, got %+v", first)
+	}
+	reply := first.Comments[0]
+	if reply.Author.Association != model.AssociationOwner {
+		t.Errorf("want reply by owner, got %q", reply.Author.Association)
+	}
+	if want := "https://gitlab.com/hugo-mods/blog/-/issues/3#note_3"; reply.URL != want {
+		t.Errorf("want URL %q, got %q", want, reply.URL)
+	}
+}
@@ -0,0 +1,76 @@
+package gitlab
+
+import "time"
+
+type User struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	Name      string `json:"name"`
+	State     string `json:"state"`
+	AvatarURL string `json:"avatar_url"`
+	WebURL    string `json:"web_url"`
+	Bot       bool   `json:"bot"`
+}
+
+// Member is a user with access to the project, directly or inherited from its groups.
+type Member struct {
+	User
+	// AccessLevel is e.g. 30 for developers, 40 for maintainers and 50 for owners.
+	AccessLevel int `json:"access_level"`
+}
+
+type Label struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+type Issue struct {
+	ID          int64    `json:"id"`
+	IID         int      `json:"iid"`
+	WebURL      string   `json:"web_url"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Author      User     `json:"author"`
+	Labels      []string `json:"labels"`
+	State       string   `json:"state"`
+	Locked      *bool    `json:"discussion_locked"`
+	Upvotes     int      `json:"upvotes"`
+	NotesCount  int      `json:"user_notes_count"`
+	// Confidential issues are only visible to project members and never exported.
+	Confidential bool      `json:"confidential"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Discussion is a thread of notes. Its first note is the comment, the following notes are replies.
+type Discussion struct {
+	ID             string `json:"id"`
+	IndividualNote bool   `json:"individual_note"`
+	Notes          []Note `json:"notes"`
+}
+
+type Note struct {
+	ID     int64  `json:"id"`
+	Body   string `json:"body"`
+	Author User   `json:"author"`
+	System bool   `json:"system"`
+	// Internal notes are only visible to project members and never exported.
+	// Confidential is their name before GitLab 15.5.
+	Internal     bool      `json:"internal"`
+	Confidential bool      `json:"confidential"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type AwardEmoji struct {
+	Name string `json:"name"`
+	User User   `json:"user"`
+}
+
+// Thread is an issue together with its discussions and the award emoji of the issue and its notes.
+type Thread struct {
+	Issue       Issue
+	Awards      []AwardEmoji
+	Discussions []Discussion
+	NoteAwards  map[int64][]AwardEmoji
+}
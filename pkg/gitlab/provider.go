@@ -0,0 +1,211 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+	"github.com/hugo-mods/discussions-bridge/pkg/provider"
+	"github.com/hugo-mods/discussions-bridge/pkg/rest"
+)
+
+var (
+	_ provider.DiscussionProvider = (*Provider)(nil)
+	_ provider.Commenter          = (*Provider)(nil)
+	_ provider.Locker             = (*Provider)(nil)
+	_ provider.Closer             = (*Provider)(nil)
+	_ provider.Labeler            = (*Provider)(nil)
+)
+
+// Provider serves the issues of a GitLab project as independent models.
+// Categories are the project's labels and discussion IDs are issue IIDs.
+type Provider struct {
+	client       *Client
+	opts         model.Options
+	labels       []Label
+	associations model.Associations
+}
+
+func NewProvider(client *Client, opts model.Options) *Provider {
+	return &Provider{client: client, opts: opts}
+}
+
+func (p *Provider) Categories(ctx context.Context) (model.Categories, error) {
+	if err := p.loadLabels(ctx); err != nil {
+		return nil, err
+	}
+	categories := make(model.Categories, len(p.labels))
+	for i, l := range p.labels {
+		categories[i] = model.Category{ID: strconv.FormatInt(l.ID, 10), Name: l.Name}
+	}
+	return categories, nil
+}
+
+func (p *Provider) Discussions(ctx context.Context, categoryID string) ([]model.Discussion, error) {
+	label, err := p.label(ctx, categoryID)
+	if err != nil {
+		return nil, err
+	}
+	issues, err := p.client.Issues(ctx, label.Name)
+	if err != nil {
+		return nil, err
+	}
+	ds := make([]model.Discussion, 0, len(issues))
+	for _, issue := range issues {
+		if issue.Confidential {
+			continue
+		}
+		d, err := p.discussion(ctx, issue)
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, *d)
+	}
+	return ds, nil
+}
+
+func (p *Provider) Discussion(ctx context.Context, id string) (*model.Discussion, error) {
+	iid, err := rest.ParseNumber(id)
+	if err != nil {
+		return nil, err
+	}
+	issue, err := p.client.Issue(ctx, iid)
+	if err != nil {
+		return nil, err
+	}
+	if issue.Confidential {
+		return nil, fmt.Errorf("issue %d is confidential", iid)
+	}
+	return p.discussion(ctx, *issue)
+}
+
+func (p *Provider) discussion(ctx context.Context, issue Issue) (*model.Discussion, error) {
+	if err := p.loadAssociations(ctx); err != nil {
+		return nil, err
+	}
+	t, err := p.client.Thread(ctx, issue)
+	if err != nil {
+		return nil, err
+	}
+	d := ToModelDiscussion(t, p.associations, p.opts)
+	if !p.client.awardUsers {
+		d.OmitReactionUsers()
+	}
+	return d, nil
+}
+
+// CreateDiscussion creates an issue with the category's label and returns its IID.
+func (p *Provider) CreateDiscussion(ctx context.Context, categoryID, title, body string) (string, error) {
+	label, err := p.label(ctx, categoryID)
+	if err != nil {
+		return "", err
+	}
+	issue, err := p.client.CreateIssue(ctx, title, body, label.Name)
+	if err != nil {
+		return "", err
+	}
+	return strconv.Itoa(issue.IID), nil
+}
+
+func (p *Provider) UpdateDiscussion(ctx context.Context, id, title, body string) error {
+	iid, err := rest.ParseNumber(id)
+	if err != nil {
+		return err
+	}
+	return p.client.EditIssue(ctx, iid, title, body)
+}
+
+func (p *Provider) AddComment(ctx context.Context, id, body string) error {
+	iid, err := rest.ParseNumber(id)
+	if err != nil {
+		return err
+	}
+	return p.client.CreateNote(ctx, iid, body)
+}
+
+func (p *Provider) LockDiscussion(ctx context.Context, id string) error {
+	iid, err := rest.ParseNumber(id)
+	if err != nil {
+		return err
+	}
+	return p.client.LockIssue(ctx, iid)
+}
+
+// CloseDiscussion closes the issue. Issues have no close reason.
+func (p *Provider) CloseDiscussion(ctx context.Context, id string, reason provider.CloseReason) error {
+	iid, err := rest.ParseNumber(id)
+	if err != nil {
+		return err
+	}
+	return p.client.CloseIssue(ctx, iid)
+}
+
+func (p *Provider) AddLabel(ctx context.Context, id, label string) error {
+	iid, err := rest.ParseNumber(id)
+	if err != nil {
+		return err
+	}
+	return p.client.AddLabels(ctx, iid, label)
+}
+
+// namespace is the user or group the project belongs to.
+func (p *Provider) namespace() string {
+	if i := strings.LastIndex(p.client.project, "/"); i >= 0 {
+		return p.client.project[:i]
+	}
+	return ""
+}
+
+// Access levels of project members, see https://docs.gitlab.com/ee/api/members.html#roles.
+const (
+	developerAccess = 30
+	ownerAccess     = 50
+)
+
+// loadAssociations associates the project's members: owners and the user of a personal namespace as owners,
+// and developers and maintainers, who can push, as members. Reporters and guests are not associated.
+func (p *Provider) loadAssociations(ctx context.Context) error {
+	if p.associations != nil {
+		return nil
+	}
+	members, err := p.client.Members(ctx)
+	if err != nil {
+		return err
+	}
+	p.associations = model.Associations{p.namespace(): model.AssociationOwner}
+	for _, m := range members {
+		switch {
+		case m.AccessLevel >= ownerAccess:
+			p.associations[m.Username] = model.AssociationOwner
+		case m.AccessLevel >= developerAccess && m.Username != p.namespace():
+			p.associations[m.Username] = model.AssociationMember
+		}
+	}
+	return nil
+}
+
+func (p *Provider) loadLabels(ctx context.Context) error {
+	if p.labels != nil {
+		return nil
+	}
+	labels, err := p.client.Labels(ctx)
+	if err != nil {
+		return err
+	}
+	p.labels = labels
+	return nil
+}
+
+func (p *Provider) label(ctx context.Context, id string) (*Label, error) {
+	if err := p.loadLabels(ctx); err != nil {
+		return nil, err
+	}
+	for i := range p.labels {
+		if strconv.FormatInt(p.labels[i].ID, 10) == id {
+			return &p.labels[i], nil
+		}
+	}
+	return nil, fmt.Errorf("could not find label with ID %s", id)
+}
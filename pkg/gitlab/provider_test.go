@@ -0,0 +1,178 @@
+package gitlab_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/gitlab"
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+)
+
+func TestProviderThreads(t *testing.T) {
+	var created map[string]interface{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/hugo-mods/blog/labels", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.RawPath != "/api/v4/projects/hugo-mods%2Fblog/labels" {
+			t.Errorf("want escaped project path, got %q", r.URL.RawPath)
+		}
+		w.Write([]byte(`[{"id": 7, "name": "Blog"}]`))
+	})
+	mux.HandleFunc("/api/v4/projects/hugo-mods/blog/issues", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			json.NewDecoder(r.Body).Decode(&created)
+			w.Write([]byte(`{"iid": 2}`))
+			return
+		}
+		if got := r.URL.Query().Get("labels"); got != "Blog" {
+			t.Errorf("want issues filtered by label Blog, got %q", got)
+		}
+		w.Write([]byte(`[
+			{"iid": 1, "title": "Hello", "description": "Blog post: https://example.com/hello/", "user_notes_count": 4},
+			{"iid": 3, "title": "Secret", "description": "Blog post: https://example.com/secret/", "confidential": true, "user_notes_count": 1}
+		]`))
+	})
+	mux.HandleFunc("/api/v4/projects/hugo-mods/blog/issues/3", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"iid": 3, "title": "Secret", "confidential": true}`))
+	})
+	mux.HandleFunc("/api/v4/projects/hugo-mods/blog/issues/3/discussions", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("want the notes of the confidential issue not to be fetched")
+	})
+	mux.HandleFunc("/api/v4/projects/hugo-mods/blog/issues/1/discussions", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[
+			{"id": "d1", "notes": [
+				{"id": 11, "body": "Nice!", "author": {"username": "reader"}},
+				{"id": 12, "body": "Let's discuss this internally", "author": {"username": "hugo-mods"}, "internal": true},
+				{"id": 13, "body": "Thanks!", "author": {"username": "kdevo"}}
+			]},
+			{"id": "d2", "individual_note": true, "notes": [{"id": 14, "body": "added ~7 label", "author": {"username": "kdevo"}, "system": true}]},
+			{"id": "d3", "individual_note": true, "notes": [{"id": 15, "body": "Old internal note", "author": {"username": "kdevo"}, "confidential": true}]}
+		]`))
+	})
+	mux.HandleFunc("/api/v4/projects/hugo-mods/blog/members/all", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"username": "kdevo", "access_level": 30}, {"username": "reader", "access_level": 10}]`))
+	})
+	mux.HandleFunc("/api/v4/projects/hugo-mods/blog/issues/1/award_emoji", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"name": "heart", "user": {"username": "reader"}}]`))
+	})
+	mux.HandleFunc("/api/v4/projects/hugo-mods/blog/issues/1/notes/", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/projects/hugo-mods/blog/issues/1/notes/11/award_emoji":
+			w.Write([]byte(`[{"name": "thumbsup", "user": {"username": "hugo-mods"}}]`))
+		case "/api/v4/projects/hugo-mods/blog/issues/1/notes/13/award_emoji":
+			w.Write([]byte(`[]`))
+		default:
+			t.Errorf("want award emoji of exported notes only, got request of %s", r.URL.Path)
+			w.Write([]byte(`[]`))
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	p := gitlab.NewProvider(gitlab.New(srv.Client(), srv.URL, "hugo-mods/blog"), model.Options{})
+	ctx := context.Background()
+	categories, err := p.Categories(ctx)
+	if err != nil {
+		t.Fatalf("could not get categories: %v", err)
+	}
+	category := categories.ByName("Blog")
+	if category == nil {
+		t.Fatalf("want label Blog, got %+v", categories)
+	}
+
+	ds, err := p.Discussions(ctx, category.ID)
+	if err != nil {
+		t.Fatalf("could not get discussions: %v", err)
+	}
+	if len(ds) != 1 || ds[0].ID != "1" {
+		t.Fatalf("want confidential issue #3 to be skipped, got %+v", ds)
+	}
+	if len(ds[0].Comments) != 1 {
+		t.Fatalf("want only the thread without system and internal notes, got %+v", ds[0].Comments)
+	}
+	comment := ds[0].Comments[0]
+	if comment.Body != "Nice!" || len(comment.Reactions[model.ThumbsUp]) != 1 {
+		t.Errorf("want the first note with its award emoji as comment, got %+v", comment)
+	}
+	if len(comment.Comments) != 1 || comment.CommentsCount != 1 || comment.Comments[0].Body != "Thanks!" {
+		t.Errorf("want the following note without the internal one as reply, got %+v", comment.Comments)
+	}
+	if comment.Author.Association != model.AssociationNone || comment.Comments[0].Author.Association != model.AssociationMember {
+		t.Errorf("want the guest reader not associated and the developer kdevo as member, got %+v", comment)
+	}
+
+	if d, err := p.Discussion(ctx, "3"); err == nil {
+		t.Errorf("want error for confidential issue, got %+v", d)
+	}
+
+	id, err := p.CreateDiscussion(ctx, category.ID, "New", "Blog post: https://example.com/new/")
+	if err != nil {
+		t.Fatalf("could not create discussion: %v", err)
+	}
+	if id != "2" || created["labels"] != "Blog" {
+		t.Errorf("want issue 2 created with label Blog, got %q with %v", id, created["labels"])
+	}
+}
+
+func TestProviderPagination(t *testing.T) {
+	// page serves the given number of full pages of 100 items, followed by a page of the last items.
+	page := func(full int, item func(i int) map[string]interface{}, last ...map[string]interface{}) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if got := r.URL.Query().Get("per_page"); got != "100" {
+				t.Errorf("want 100 items per page, got %q", got)
+			}
+			n, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			var items []map[string]interface{}
+			switch {
+			case n <= full:
+				for i := 0; i < 100; i++ {
+					items = append(items, item((n-1)*100+i))
+				}
+			case n == full+1:
+				items = last
+			default:
+				t.Errorf("want no request after the last page, got page %d of %s", n, r.URL.Path)
+			}
+			json.NewEncoder(w).Encode(items)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/projects/blog/labels", page(1, func(i int) map[string]interface{} {
+		return map[string]interface{}{"id": i, "name": fmt.Sprintf("Label %d", i)}
+	}, map[string]interface{}{"id": 100, "name": "Blog"}))
+	mux.HandleFunc("/api/v4/projects/blog/members/all", page(2, func(i int) map[string]interface{} {
+		return map[string]interface{}{"username": fmt.Sprintf("guest%d", i), "access_level": 10}
+	}, map[string]interface{}{"username": "kdevo", "access_level": 40}))
+	mux.HandleFunc("/api/v4/projects/blog/issues", page(2, func(i int) map[string]interface{} {
+		return map[string]interface{}{"iid": i + 1, "author": map[string]interface{}{"username": "kdevo"}}
+	}))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	client := gitlab.New(srv.Client(), srv.URL, "blog").WithAwards(false).WithMaxDiscussions(150)
+	p := gitlab.NewProvider(client, model.Options{})
+	ctx := context.Background()
+	categories, err := p.Categories(ctx)
+	if err != nil {
+		t.Fatalf("could not get categories: %v", err)
+	}
+	category := categories.ByName("Blog")
+	if len(categories) != 101 || category == nil {
+		t.Fatalf("want labels of both pages, got %d labels", len(categories))
+	}
+
+	ds, err := p.Discussions(ctx, category.ID)
+	if err != nil {
+		t.Fatalf("could not get discussions: %v", err)
+	}
+	if len(ds) != 150 || ds[149].Number != 150 {
+		t.Fatalf("want issues of two pages up to the maximum, got %d", len(ds))
+	}
+	if got := ds[0].Author.Association; got != model.AssociationMember {
+		t.Errorf("want kdevo of the last members page associated as member, got %q", got)
+	}
+}
@@ -0,0 +1,218 @@
+// Package migrate moves the threads of utterances, i.e. GitHub issues, to discussions.
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/github"
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+	"github.com/hugo-mods/discussions-bridge/pkg/site"
+)
+
+// Client is the part of github.Client needed for the migration.
+// GitHub's conversion of issues to discussions is not available via its API,
+// so discussions are created and the comments are copied.
+type Client interface {
+	Issues(ctx context.Context, labels ...string) ([]github.Issue, error)
+	CreateDiscussion(ctx context.Context, categoryID, title, body string) (string, error)
+	AddDiscussionComment(ctx context.Context, discussionID, body string) (string, error)
+}
+
+// Mapping records the migrated issues by URL, so that an interrupted migration can be resumed.
+type Mapping map[string]*Migrated
+
+type Migrated struct {
+	Page         string `json:"page"`
+	DiscussionID string `json:"discussionId"`
+	// Comments is the number of comments that have been copied.
+	Comments int  `json:"comments"`
+	Done     bool `json:"done"`
+}
+
+// LoadMapping reads the mapping file. A missing file results in an empty mapping.
+func LoadMapping(path string) (Mapping, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Mapping{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read mapping: %w", err)
+	}
+	m := Mapping{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("could not unmarshal mapping: %w", err)
+	}
+	return m, nil
+}
+
+func (m Mapping) Save(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not marshal JSON: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return fmt.Errorf("could not create directories to write mapping: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0666); err != nil {
+		return fmt.Errorf("could not write mapping: %v", err)
+	}
+	return nil
+}
+
+type Migrator struct {
+	client      Client
+	site        *site.Site
+	categoryID  string
+	labels      []string
+	existing    []model.Discussion
+	mapping     Mapping
+	mappingFile string
+}
+
+// New creates a migrator that relates issues to pages using the site's relations and saves its progress to mappingFile.
+func New(client Client, s *site.Site, categoryID string, mappingFile string) (*Migrator, error) {
+	mapping, err := LoadMapping(mappingFile)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		client:      client,
+		site:        s,
+		categoryID:  categoryID,
+		mapping:     mapping,
+		mappingFile: mappingFile,
+	}, nil
+}
+
+// WithLabels only migrates issues with any of the labels, e.g. the label utterances has been configured with.
+func (m *Migrator) WithLabels(labels ...string) *Migrator {
+	m.labels = labels
+	return m
+}
+
+// WithExisting sets the discussions of the category, e.g. those created by giscus or the bridge itself.
+// They are related to pages like the issues, and issues of these pages are copied into the existing discussion
+// instead of creating another one.
+func (m *Migrator) WithExisting(ds []model.Discussion) *Migrator {
+	m.existing = ds
+	return m
+}
+
+// Report lists the migrated issues by URL.
+type Report struct {
+	Migrated []string
+	// Existing are the migrated issues that have been copied into an existing discussion.
+	Existing []string
+	Skipped  []string
+}
+
+// Migrate creates a discussion for each issue that belongs to one of the pages, with the page's opener and a link
+// to the original issue, and copies the issue's comments. If the page already has a discussion (see WithExisting),
+// the comments are copied into it. Of several issues of a page, the site's winner gets the discussion and the
+// comments of the others are copied into it afterwards. Progress is saved after each step.
+func (m *Migrator) Migrate(ctx context.Context, pages map[string]site.Page) (Report, error) {
+	var report Report
+	issues, err := m.client.Issues(ctx, m.labels...)
+	if err != nil {
+		return report, fmt.Errorf("could not get issues: %w", err)
+	}
+	byURL := make(map[string]github.Issue, len(issues))
+	ds := make([]model.Discussion, len(issues))
+	for i, issue := range issues {
+		byURL[issue.URL] = issue
+		ds[i] = model.Discussion{
+			Title:   issue.Title,
+			Message: model.Message{URL: issue.URL, Number: issue.Number, CreatedAt: issue.CreatedAt, Body: issue.Body},
+			Closed:  issue.Closed,
+		}
+	}
+	related, duplicates := m.site.RelateWithDuplicates(ds, pages)
+	losers := make(map[string][]model.Discussion, len(duplicates))
+	for _, dup := range duplicates {
+		losers[dup.Page] = dup.Losers
+	}
+	existing := m.site.Relate(append([]model.Discussion{}, m.existing...), pages)
+	urls := make([]string, 0, len(related))
+	for url := range related {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+
+	for _, url := range urls {
+		discussionID := existing[url].ID
+		for _, d := range append([]model.Discussion{related[url]}, losers[url]...) {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			issue := byURL[d.URL]
+			migrated, ok := m.mapping[issue.URL]
+			if ok && migrated.Done {
+				report.Skipped = append(report.Skipped, issue.URL)
+				discussionID = migrated.DiscussionID
+				continue
+			}
+			if !ok {
+				migrated = &Migrated{Page: url, DiscussionID: discussionID}
+				if _, found := existing[url]; found {
+					report.Existing = append(report.Existing, issue.URL)
+				}
+				m.mapping[issue.URL] = migrated
+			}
+			if err := m.migrate(ctx, issue, pages[url], migrated); err != nil {
+				return report, fmt.Errorf("could not migrate %s: %w", issue.URL, err)
+			}
+			report.Migrated = append(report.Migrated, issue.URL)
+			discussionID = migrated.DiscussionID
+		}
+	}
+	return report, nil
+}
+
+func (m *Migrator) migrate(ctx context.Context, issue github.Issue, page site.Page, migrated *Migrated) error {
+	if migrated.DiscussionID == "" {
+		d, err := m.site.NewDiscussion(page)
+		if err != nil {
+			return err
+		}
+		body := fmt.Sprintf("%s\n\n_Migrated from %s_", d.Body, issue.URL)
+		id, err := m.client.CreateDiscussion(ctx, m.categoryID, d.Title, body)
+		if err != nil {
+			return err
+		}
+		migrated.DiscussionID = id
+		if err := m.mapping.Save(m.mappingFile); err != nil {
+			return err
+		}
+	}
+	for i := migrated.Comments; i < len(issue.Comments.Nodes); i++ {
+		if _, err := m.client.AddDiscussionComment(ctx, migrated.DiscussionID, CommentBody(issue.Comments.Nodes[i])); err != nil {
+			return err
+		}
+		migrated.Comments = i + 1
+		if err := m.mapping.Save(m.mappingFile); err != nil {
+			return err
+		}
+	}
+	migrated.Done = true
+	return m.mapping.Save(m.mappingFile)
+}
+
+// CommentBody names the original author and links the original comment, since comments are created by the bridge.
+// The author is not mentioned with "@" to avoid notifying everyone again.
+func CommentBody(c github.IssueComment) string {
+	login := c.Author.Login
+	if login == "" {
+		login = "ghost"
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "**%s** [commented](%s) on %s:\n\n", login, c.URL, c.CreatedAt.Format("2006-01-02"))
+	b.WriteString(c.Body)
+	return b.String()
+}
@@ -0,0 +1,173 @@
+package migrate_test
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/github"
+	"github.com/hugo-mods/discussions-bridge/pkg/migrate"
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+	"github.com/hugo-mods/discussions-bridge/pkg/site"
+)
+
+const page = "https://hugo-mods.github.io/blog/icons/"
+
+var pages = map[string]site.Page{
+	page: {URL: page, Title: "Icons"},
+}
+
+type fakeClient struct {
+	issues  []github.Issue
+	created []string
+	// comments are the copied comments by discussion ID.
+	comments map[string][]string
+	failAt   int
+}
+
+func (c *fakeClient) Issues(ctx context.Context, labels ...string) ([]github.Issue, error) {
+	return c.issues, nil
+}
+
+func (c *fakeClient) CreateDiscussion(ctx context.Context, categoryID, title, body string) (string, error) {
+	c.created = append(c.created, body)
+	return "D1", nil
+}
+
+func (c *fakeClient) AddDiscussionComment(ctx context.Context, discussionID, body string) (string, error) {
+	if c.failAt > 0 && c.copied()+1 == c.failAt {
+		c.failAt = 0
+		return "", errors.New("secondary rate limit")
+	}
+	if c.comments == nil {
+		c.comments = map[string][]string{}
+	}
+	c.comments[discussionID] = append(c.comments[discussionID], body)
+	return "C", nil
+}
+
+func (c *fakeClient) copied() int {
+	n := 0
+	for _, cs := range c.comments {
+		n += len(cs)
+	}
+	return n
+}
+
+// issue returns an utterances issue of the page path with comments of the given bodies.
+func issue(number int, path string, bodies ...string) github.Issue {
+	i := github.Issue{Number: number, URL: "https://github.com/hugo-mods/blog/issues/" + strconv.Itoa(number), Title: path}
+	for _, body := range bodies {
+		i.Comments.Nodes = append(i.Comments.Nodes, github.IssueComment{Body: body, Author: github.Author{Login: "reader"}})
+	}
+	return i
+}
+
+func newSite(t *testing.T) *site.Site {
+	s, err := site.New("", "", "Blog post: {{ .URL }}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// like the migrate-utterances command, which relates the bridge's discussions by opener:
+	return s.WithRelations(site.RelationOpener, site.RelationPathname)
+}
+
+func TestMigrateResumes(t *testing.T) {
+	icons := issue(1, "blog/icons/", "first", "second", "third")
+	client := &fakeClient{issues: []github.Issue{icons, issue(2, "unrelated")}, failAt: 2}
+	s := newSite(t)
+	mappingFile := filepath.Join(t.TempDir(), "migration.json")
+
+	for run := 0; run < 3; run++ {
+		m, err := migrate.New(client, s, "C1", mappingFile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		report, err := m.Migrate(context.Background(), pages)
+		switch run {
+		case 0:
+			if err == nil {
+				t.Fatal("want error of first run")
+			}
+		case 1:
+			if err != nil || len(report.Migrated) != 1 {
+				t.Fatalf("want resumed migration, got %v, %v", report, err)
+			}
+		case 2:
+			if err != nil || len(report.Skipped) != 1 {
+				t.Fatalf("want migrated issue to be skipped, got %v, %v", report, err)
+			}
+		}
+	}
+
+	if want := []string{"Blog post: https://hugo-mods.github.io/blog/icons/\n\n_Migrated from https://github.com/hugo-mods/blog/issues/1_"}; !reflect.DeepEqual(want, client.created) {
+		t.Errorf("want one discussion:\n  want=%q\n   got=%q", want, client.created)
+	}
+	if len(client.comments["D1"]) != 3 {
+		t.Errorf("want each comment copied once, got %q", client.comments)
+	}
+	mapping, err := migrate.LoadMapping(mappingFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := migrate.Mapping{icons.URL: {Page: page, DiscussionID: "D1", Comments: 3, Done: true}}
+	if !reflect.DeepEqual(want, mapping) {
+		t.Errorf("unexpected mapping:\n  want=%v\n   got=%v", want, mapping)
+	}
+}
+
+func TestMigrateIntoExisting(t *testing.T) {
+	client := &fakeClient{issues: []github.Issue{issue(1, "blog/icons/", "first", "second")}}
+	existing := []model.Discussion{
+		{Message: model.Message{ID: "D8", Body: "Blog post: https://hugo-mods.github.io/blog/other/"}},
+		{Message: model.Message{ID: "D9", Body: "Blog post: " + page}},
+	}
+
+	m, err := migrate.New(client, newSite(t), "C1", filepath.Join(t.TempDir(), "migration.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := m.WithExisting(existing).Migrate(context.Background(), pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(client.created) != 0 {
+		t.Errorf("want no discussion to be created, got %q", client.created)
+	}
+	if len(client.comments["D9"]) != 2 {
+		t.Errorf("want comments copied into the existing discussion, got %q", client.comments)
+	}
+	if len(report.Migrated) != 1 || len(report.Existing) != 1 {
+		t.Errorf("want issue migrated into existing discussion, got %+v", report)
+	}
+}
+
+func TestMigrateDuplicates(t *testing.T) {
+	newer := issue(1, "blog/icons/", "newer")
+	newer.CreatedAt = time.Date(2022, 2, 1, 0, 0, 0, 0, time.UTC)
+	older := issue(2, "blog/icons/", "older", "oldest")
+	older.CreatedAt = time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	client := &fakeClient{issues: []github.Issue{newer, older}}
+
+	m, err := migrate.New(client, newSite(t), "C1", filepath.Join(t.TempDir(), "migration.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := m.Migrate(context.Background(), pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"Blog post: https://hugo-mods.github.io/blog/icons/\n\n_Migrated from " + older.URL + "_"}; !reflect.DeepEqual(want, client.created) {
+		t.Errorf("want one discussion for the oldest issue:\n  want=%q\n   got=%q", want, client.created)
+	}
+	if len(client.comments["D1"]) != 3 {
+		t.Errorf("want comments of both issues copied into the discussion, got %q", client.comments)
+	}
+	if want := []string{older.URL, newer.URL}; !reflect.DeepEqual(want, report.Migrated) {
+		t.Errorf("want both issues migrated, the oldest first:\n  want=%v\n   got=%v", want, report.Migrated)
+	}
+}
@@ -1,19 +1,37 @@
 package model
 
+import "time"
+
 // Message is anything that is written by an author and can have replies.
 type Message struct {
+	// ID uniquely identifies the message at its origin.
+	ID string `json:"id"`
+	// Number identifies the message for humans, e.g. the discussion number or the comment number used in URL anchors.
+	Number int `json:"number"`
 	// URL where the comment is located at.
 	URL string `json:"url"`
+	// CreatedAt is the time when the message has been written.
+	CreatedAt time.Time `json:"createdAt"`
+	// UpdatedAt is the last time the message or anything related to it (e.g. reactions) has changed.
+	UpdatedAt time.Time `json:"updatedAt"`
+	// LastEditedAt is the last time the body has been edited. Not given if the message has never been edited.
+	LastEditedAt *time.Time `json:"lastEditedAt,omitempty"`
+	// Edited is true if the body has been edited after creation.
+	Edited bool `json:"edited"`
 	// Author is the user who created the comment.
 	Author Author `json:"author"`
 	// Body is the discussion's main text.
 	Body string `json:"body"`
 	// BodyMIME is the MIME type of the Body, e.g. "text/markdown" or "text/plain".
 	BodyMIME string `json:"bodyMimeType"`
+	// BodyHTML is the sanitised HTML rendering of the Body. Optional, only given if HTML export is enabled.
+	BodyHTML string `json:"bodyHtml,omitempty"`
 	// UpvotesCount describes how many times the discussion has been found useful.
 	UpvotesCount int `json:"upvotesCount"`
-	// Reactions are used to express a feeling by an emoji.
+	// Reactions are used to express a feeling by an emoji. Optional, not given if only counts are exported.
 	Reactions Reactions `json:"reactions,omitempty"`
+	// ReactionCounts are the total numbers of reactions per emoji.
+	ReactionCounts []ReactionCount `json:"reactionCounts,omitempty"`
 }
 
 // Discussions can have comments that are arbitrary nested.
@@ -23,10 +41,71 @@ type Discussion struct {
 	Title string `json:"title"`
 	// Comments are the comments of the discussion.
 	Comments []Comment `json:"comments"`
+	// Answerable is true if the discussion is a question, i.e. a comment can be chosen as its answer.
+	Answerable bool `json:"answerable"`
+	// Answered is true if a comment has been chosen as answer. The answer is always the first comment.
+	Answered bool `json:"answered"`
+	// AnswerChosenAt is the time when an answer has been chosen. Not given if there is none.
+	AnswerChosenAt *time.Time `json:"answerChosenAt,omitempty"`
+	// Locked is true if no further comments can be written, e.g. to show "comments closed".
+	Locked bool `json:"locked"`
+	// Closed is true if the discussion has been closed, e.g. as duplicate or because its page has been removed.
+	Closed bool `json:"closed"`
+	// Poll is the discussion's poll. Optional, not given if the discussion has no poll.
+	Poll *Poll `json:"poll,omitempty"`
+}
+
+// UpdateAnswered derives the answered state from the comments, so that a discussion is only answered
+// if its answer is exported, i.e. it has neither been hidden nor filtered.
+func (d *Discussion) UpdateAnswered() {
+	for _, c := range d.Comments {
+		if c.Answer && !c.Hidden {
+			return
+		}
+	}
+	d.Answered = false
+	d.AnswerChosenAt = nil
+}
+
+// OmitReactionUsers removes the users who reacted from the discussion and all of its comments.
+// The reaction counts are kept, e.g. for providers that can only count reactions by fetching their users.
+func (d *Discussion) OmitReactionUsers() {
+	d.Reactions = nil
+	omitReactionUsers(d.Comments)
+}
+
+func omitReactionUsers(cs []Comment) {
+	for i := range cs {
+		cs[i].Reactions = nil
+		omitReactionUsers(cs[i].Comments)
+	}
+}
+
+// Poll is a question that can be answered by voting for one of its options.
+type Poll struct {
+	// Question is what the poll is about.
+	Question string `json:"question"`
+	// Options are the possible answers.
+	Options []PollOption `json:"options"`
+	// VotesCount is the total number of votes across all options.
+	VotesCount int `json:"votesCount"`
+}
+
+type PollOption struct {
+	// Option is the text of the answer.
+	Option string `json:"option"`
+	// VotesCount is the number of votes for this option.
+	VotesCount int `json:"votesCount"`
 }
 
 type Comment struct {
 	Message
+	// Answer is true if the comment has been chosen as the answer of its discussion.
+	Answer bool `json:"answer,omitempty"`
+	// Hidden is true if the comment has been hidden by a moderator or deleted. Its content is never exported.
+	Hidden bool `json:"hidden,omitempty"`
+	// HiddenReason describes why the comment is hidden, e.g. "spam", "off-topic" or "deleted".
+	HiddenReason string `json:"hiddenReason,omitempty"`
 	// Comments can be commented, too. Optional, can be "collapsed". In this case, only CommentsCount is given.
 	Comments []Comment `json:"comments,omitempty"`
 	// CommentsCount describes how many comment replies there are and is always given.
@@ -39,6 +118,42 @@ type Author struct {
 	FullName string
 	// PictureURL is the author's profile picture URL.
 	PictureURL string
+	// ProfileURL is the author's profile page URL.
+	ProfileURL string
+	// Bot is true if the author is an automated account, e.g. an app.
+	Bot bool
+	// Ghost is true if the author's account has been deleted.
+	Ghost bool
+	// Association describes the author's role in the repository the discussion belongs to.
+	Association Association
+}
+
+// Association is the role of an author, e.g. to badge replies of maintainers.
+type Association string
+
+const (
+	AssociationOwner        Association = "owner"
+	AssociationMember       Association = "member"
+	AssociationCollaborator Association = "collaborator"
+	AssociationContributor  Association = "contributor"
+	AssociationFirstTimer   Association = "first-timer"
+	AssociationNone         Association = "none"
+)
+
+// Maintainer reports whether the association grants write access, i.e. owners, members and collaborators.
+func (a Association) Maintainer() bool {
+	return a == AssociationOwner || a == AssociationMember || a == AssociationCollaborator
+}
+
+// Associations map user names to their role in a repository, for providers that do not return it with the author.
+type Associations map[string]Association
+
+// Of returns the association of the user or AssociationNone if it is unknown.
+func (as Associations) Of(name string) Association {
+	if a, ok := as[name]; ok {
+		return a
+	}
+	return AssociationNone
 }
 
 type User struct {
@@ -62,5 +177,53 @@ const (
 	ThoughtBalloon EmojiCode = ":thought_balloon:"
 )
 
+var emojiChars = map[EmojiCode]string{
+	ThumbsUp:       "👍",
+	ThumbsDown:     "👎",
+	Smile:          "😄",
+	Party:          "🎉",
+	Confused:       "😕",
+	Heart:          "❤️",
+	Rocket:         "🚀",
+	Eyes:           "👀",
+	Thinking:       "🤔",
+	ThoughtBalloon: "💭",
+}
+
+// Char returns the Unicode character of the emoji or an empty string if it is unknown.
+func (c EmojiCode) Char() string {
+	return emojiChars[c]
+}
+
 // Reactions map an emoji to users (the users who have selected this emoji).
 type Reactions map[EmojiCode][]User
+
+// ReactionCount is the total number of reactions with the same emoji.
+type ReactionCount struct {
+	// Emoji is the code of the emoji, e.g. ":+1:".
+	Emoji EmojiCode `json:"emoji"`
+	// Char is the emoji's Unicode character, e.g. "👍".
+	Char string `json:"char"`
+	// Count is the number of users who have reacted with the emoji.
+	Count int `json:"count"`
+}
+
+// Category groups discussions, e.g. a GitHub discussion category or an issue label.
+type Category struct {
+	ID   string
+	Name string
+	// Answerable is true if comments of the category's discussions can be chosen as answer.
+	Answerable bool
+}
+
+type Categories []Category
+
+// ByName returns the first category with the given name or nil if there is none.
+func (cs Categories) ByName(name string) *Category {
+	for i := range cs {
+		if cs[i].Name == name {
+			return &cs[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,7 @@
+package model
+
+// Options configure the conversion from a provider's data to the independent model.
+type Options struct {
+	// HiddenPlaceholders keeps minimized and deleted comments as placeholders without content instead of excluding them.
+	HiddenPlaceholders bool
+}
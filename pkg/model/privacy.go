@@ -0,0 +1,152 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// ReactionUsers defines how the users who reacted are exported.
+type ReactionUsers string
+
+const (
+	// ReactionUsersKeep exports the users' names as-is.
+	ReactionUsersKeep ReactionUsers = ""
+	// ReactionUsersHash exports pseudonyms derived from the users' names.
+	ReactionUsersHash ReactionUsers = "hash"
+	// ReactionUsersOmit does not export the users at all, only the reaction counts remain.
+	ReactionUsersOmit ReactionUsers = "omit"
+)
+
+// Anonymous is the name of authors who have opted out from being shown.
+const Anonymous = "anonymous"
+
+// Privacy controls which user data is exported. The zero value exports everything.
+type Privacy struct {
+	// ReactionUsers defines how the users who reacted are exported.
+	ReactionUsers ReactionUsers
+	// Salt is prepended to user names before hashing them.
+	Salt string
+	// OmitAvatars removes the authors' profile pictures.
+	OmitAvatars bool
+	// AvatarProxy is prepended to the query-escaped profile picture URLs, e.g. "https://images.example.com/?url=".
+	AvatarProxy string
+	// ServerURL is where profiles of mentioned users are linked to in HTML bodies. Defaults to "https://github.com".
+	ServerURL string
+	// AnonymousLogins are users whose comments are shown as written by an anonymous author.
+	// Their reactions are omitted.
+	AnonymousLogins []string
+	// StripMentions replaces @mentions in bodies.
+	StripMentions bool
+	// StripEmails replaces email addresses in bodies.
+	StripEmails bool
+
+	// profileLinkRE matches links to user profiles as rendered for mentions in HTML bodies.
+	profileLinkRE *regexp.Regexp
+}
+
+var (
+	emailRE   = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	mentionRE = regexp.MustCompile(`(^|[^\w/@.])@[A-Za-z0-9](?:[A-Za-z0-9-]{0,38})\b`)
+)
+
+// ApplyDiscussion applies the privacy settings to the discussion and all of its comments.
+// It is meant to be called right before exporting, since moderation and relating discussions to pages
+// need the original logins, associations and bodies.
+func (p *Privacy) ApplyDiscussion(d *Discussion) {
+	p.Apply(&d.Message)
+	p.applyComments(d.Comments)
+}
+
+func (p *Privacy) applyComments(cs []Comment) {
+	for i := range cs {
+		p.Apply(&cs[i].Message)
+		p.applyComments(cs[i].Comments)
+	}
+}
+
+// Apply removes or replaces the user data of the message according to the privacy settings.
+func (p *Privacy) Apply(m *Message) {
+	if p.anonymous(m.Author.Name) {
+		m.Author = Author{User: User{Name: Anonymous}}
+	}
+	p.applyAuthor(&m.Author)
+	m.Body = p.strip(m.Body)
+	if m.BodyHTML != "" {
+		m.BodyHTML = p.strip(m.BodyHTML)
+		if p.StripMentions {
+			if p.profileLinkRE == nil {
+				p.Compile()
+			}
+			m.BodyHTML = p.profileLinkRE.ReplaceAllString(m.BodyHTML, `href="#"`)
+		}
+	}
+	m.Reactions = p.reactions(m.Reactions)
+}
+
+// Compile prepares the patterns that depend on the settings, so that they are not compiled for every message.
+// It should be called once the settings are complete.
+func (p *Privacy) Compile() {
+	serverURL := strings.TrimSuffix(p.ServerURL, "/")
+	if serverURL == "" {
+		serverURL = "https://github.com"
+	}
+	p.profileLinkRE = regexp.MustCompile(`href="` + regexp.QuoteMeta(serverURL) + `/[A-Za-z0-9-]+/?"`)
+}
+
+func (p *Privacy) applyAuthor(a *Author) {
+	switch {
+	case p.OmitAvatars:
+		a.PictureURL = ""
+	case p.AvatarProxy != "" && a.PictureURL != "":
+		a.PictureURL = p.AvatarProxy + url.QueryEscape(a.PictureURL)
+	}
+}
+
+func (p *Privacy) strip(body string) string {
+	if p.StripEmails {
+		body = emailRE.ReplaceAllString(body, "[email]")
+	}
+	if p.StripMentions {
+		body = mentionRE.ReplaceAllString(body, "${1}@"+Anonymous)
+	}
+	return body
+}
+
+func (p *Privacy) reactions(rs Reactions) Reactions {
+	if p.ReactionUsers == ReactionUsersOmit {
+		return nil
+	}
+	if p.ReactionUsers == ReactionUsersKeep && len(p.AnonymousLogins) == 0 {
+		return rs
+	}
+	res := make(Reactions, len(rs))
+	for emoji, users := range rs {
+		for _, u := range users {
+			if p.anonymous(u.Name) {
+				continue
+			}
+			if p.ReactionUsers == ReactionUsersHash {
+				u.Name = p.hash(u.Name)
+			}
+			res[emoji] = append(res[emoji], u)
+		}
+	}
+	return res
+}
+
+func (p *Privacy) anonymous(login string) bool {
+	for _, l := range p.AnonymousLogins {
+		if strings.EqualFold(l, login) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *Privacy) hash(name string) string {
+	sum := sha256.Sum256([]byte(p.Salt + strings.ToLower(name)))
+	return "user-" + hex.EncodeToString(sum[:])[:12]
+}
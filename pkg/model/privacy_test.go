@@ -0,0 +1,43 @@
+package model_test
+
+import (
+	"testing"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+)
+
+func TestPrivacyApplyDiscussion(t *testing.T) {
+	message := func(login, body string) model.Message {
+		return model.Message{Author: model.Author{User: model.User{Name: login}, PictureURL: "https://avatars.githubusercontent.com/" + login}, Body: body}
+	}
+	d := model.Discussion{
+		Message: message("kdevo", "Comments"),
+		Comments: []model.Comment{{
+			Message:  message("alice", "Thanks @bob, write me at alice@example.com"),
+			Comments: []model.Comment{{Message: message("Bob", "You're welcome!")}},
+		}},
+	}
+	d.Comments[0].Reactions = model.Reactions{model.Heart: {{Name: "bob"}, {Name: "carol"}}}
+	privacy := model.Privacy{
+		ReactionUsers:   model.ReactionUsersHash,
+		OmitAvatars:     true,
+		AnonymousLogins: []string{"bob"},
+		StripMentions:   true,
+		StripEmails:     true,
+	}
+	privacy.ApplyDiscussion(&d)
+
+	got := d.Comments[0]
+	if want := "Thanks @anonymous, write me at [email]"; got.Body != want {
+		t.Errorf("unexpected body:\n  want=%v\n   got=%v", want, got.Body)
+	}
+	if d.Author.PictureURL != "" || got.Author.PictureURL != "" {
+		t.Errorf("want avatars to be omitted, got %q and %q", d.Author.PictureURL, got.Author.PictureURL)
+	}
+	if users := got.Reactions[model.Heart]; len(users) != 1 || users[0].Name == "carol" {
+		t.Errorf("want only carol's hashed reaction, got %v", users)
+	}
+	if reply := got.Comments[0]; reply.Author.Name != model.Anonymous {
+		t.Errorf("want anonymous author of the reply, got %q", reply.Author.Name)
+	}
+}
@@ -0,0 +1,156 @@
+// Package moderation filters comments before they get published on the site.
+package moderation
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+)
+
+// Rules decide which comments are published. The zero value publishes everything.
+type Rules struct {
+	// BlockedLogins are the names of users whose comments are never published.
+	BlockedLogins []string
+	// Keywords block comments whose body matches any of the expressions.
+	Keywords []*regexp.Regexp
+	// HoldNewcomers holds back comments of first-timers and users without association
+	// until a maintainer has reacted to them. Requires the users who reacted to be fetched, not only the reaction counts.
+	HoldNewcomers bool
+	// Maintainers are the names of users who can release held comments in addition to
+	// all authors who are known to be owners, members or collaborators.
+	Maintainers []string
+	// MaxBodyLength blocks comments with longer bodies. Zero means no limit.
+	MaxBodyLength int
+}
+
+// Filtered describes a comment that has not been published.
+type Filtered struct {
+	DiscussionURL string
+	CommentURL    string
+	Author        string
+	Reason        string
+}
+
+// Report contains all filtered comments.
+type Report []Filtered
+
+func (r Report) String() string {
+	var sb strings.Builder
+	for _, f := range r {
+		fmt.Fprintf(&sb, "  %s by %s: %s\n", f.CommentURL, f.Author, f.Reason)
+	}
+	return sb.String()
+}
+
+// Keywords compiles the given regular expressions.
+func Keywords(patterns []string) ([]*regexp.Regexp, error) {
+	res := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("could not compile keyword %q: %w", p, err)
+		}
+		res[i] = re
+	}
+	return res, nil
+}
+
+// Apply filters the comments and their replies of the given discussions and reports what has been filtered.
+// Replies of a filtered comment are not published either. Hidden comments are kept, since they do not have any content.
+func (r *Rules) Apply(ds []model.Discussion) ([]model.Discussion, Report) {
+	maintainers := r.maintainers(ds)
+	var report Report
+	res := make([]model.Discussion, len(ds))
+	for i, d := range ds {
+		d.Comments = r.filter(d, d.Comments, maintainers, &report)
+		d.UpdateAnswered()
+		res[i] = d
+	}
+	return res, report
+}
+
+func (r *Rules) filter(d model.Discussion, cs []model.Comment, maintainers map[string]bool, report *Report) []model.Comment {
+	comments := make([]model.Comment, 0, len(cs))
+	for _, c := range cs {
+		if reason := r.reason(c, maintainers); reason != "" {
+			*report = append(*report, Filtered{
+				DiscussionURL: d.URL,
+				CommentURL:    c.URL,
+				Author:        c.Author.Name,
+				Reason:        reason,
+			})
+			continue
+		}
+		if len(c.Comments) > 0 {
+			replies := r.filter(d, c.Comments, maintainers, report)
+			c.CommentsCount -= len(c.Comments) - len(replies)
+			c.Comments = replies
+		}
+		comments = append(comments, c)
+	}
+	return comments
+}
+
+func (r *Rules) reason(c model.Comment, maintainers map[string]bool) string {
+	if c.Hidden {
+		return ""
+	}
+	for _, login := range r.BlockedLogins {
+		if strings.EqualFold(login, c.Author.Name) {
+			return "blocked user"
+		}
+	}
+	for _, re := range r.Keywords {
+		if re.MatchString(c.Body) {
+			return fmt.Sprintf("blocked keyword %q", re.String())
+		}
+	}
+	if r.MaxBodyLength > 0 && len([]rune(c.Body)) > r.MaxBodyLength {
+		return fmt.Sprintf("body exceeds %d characters", r.MaxBodyLength)
+	}
+	if r.HoldNewcomers && newcomer(c.Author.Association) && !approved(c, maintainers) {
+		return "held until a maintainer reacts"
+	}
+	return ""
+}
+
+func newcomer(a model.Association) bool {
+	return a == model.AssociationFirstTimer || a == model.AssociationNone || a == ""
+}
+
+func approved(c model.Comment, maintainers map[string]bool) bool {
+	for _, users := range c.Reactions {
+		for _, u := range users {
+			if maintainers[strings.ToLower(u.Name)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (r *Rules) maintainers(ds []model.Discussion) map[string]bool {
+	maintainers := make(map[string]bool, len(r.Maintainers))
+	for _, m := range r.Maintainers {
+		maintainers[strings.ToLower(m)] = true
+	}
+	add := func(a model.Author) {
+		if a.Association.Maintainer() {
+			maintainers[strings.ToLower(a.Name)] = true
+		}
+	}
+	var addComments func(cs []model.Comment)
+	addComments = func(cs []model.Comment) {
+		for _, c := range cs {
+			add(c.Author)
+			addComments(c.Comments)
+		}
+	}
+	for _, d := range ds {
+		add(d.Author)
+		addComments(d.Comments)
+	}
+	return maintainers
+}
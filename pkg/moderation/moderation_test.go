@@ -0,0 +1,99 @@
+package moderation_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+	"github.com/hugo-mods/discussions-bridge/pkg/moderation"
+)
+
+func comment(name string, association model.Association, body string) model.Comment {
+	return model.Comment{Message: model.Message{
+		URL:    "https://github.com/hugo-mods/hugo-mods.github.io/discussions/1#" + name,
+		Author: model.Author{User: model.User{Name: name}, Association: association},
+		Body:   body,
+	}}
+}
+
+func TestRulesApply(t *testing.T) {
+	approved := comment("newbie", model.AssociationFirstTimer, "First!")
+	approved.Reactions = model.Reactions{model.Heart: {{Name: "kdevo"}}}
+
+	ds := []model.Discussion{{
+		Message: model.Message{Author: model.Author{User: model.User{Name: "kdevo"}, Association: model.AssociationOwner}},
+		Comments: []model.Comment{
+			comment("spammer", model.AssociationNone, "Hello"),
+			comment("alice", model.AssociationContributor, "Buy cheap pills"),
+			comment("bob", model.AssociationContributor, "This is way too long"),
+			comment("stranger", model.AssociationNone, "Nice post"),
+			approved,
+			comment("carol", model.AssociationCollaborator, "Thanks!"),
+		},
+	}}
+	rules := moderation.Rules{
+		BlockedLogins: []string{"Spammer"},
+		Keywords:      []*regexp.Regexp{regexp.MustCompile(`(?i)cheap\s+pills`)},
+		HoldNewcomers: true,
+		MaxBodyLength: 10,
+	}
+
+	got, report := rules.Apply(ds)
+	var names []string
+	for _, c := range got[0].Comments {
+		names = append(names, c.Author.Name)
+	}
+	if len(names) != 2 || names[0] != "newbie" || names[1] != "carol" {
+		t.Errorf("unexpected published comments: %v", names)
+	}
+	if len(report) != 4 {
+		t.Errorf("want 4 filtered comments, got:\n%v", report)
+	}
+	if len(ds[0].Comments) != 6 {
+		t.Error("input discussions must not be modified")
+	}
+}
+
+func TestRulesApplyFilteredAnswer(t *testing.T) {
+	answer := comment("spammer", model.AssociationNone, "Hello")
+	answer.Answer = true
+	ds := []model.Discussion{{Answered: true, Comments: []model.Comment{answer}}}
+
+	rules := moderation.Rules{BlockedLogins: []string{"spammer"}}
+	got, _ := rules.Apply(ds)
+	if got[0].Answered {
+		t.Error("want discussion whose answer has been filtered not to be answered")
+	}
+}
+
+func TestRulesApplyReplies(t *testing.T) {
+	reply := comment("newbie", model.AssociationNone, "Me too")
+	reply.Reactions = model.Reactions{model.ThumbsUp: {{Name: "carol"}}}
+	thread := comment("stranger", model.AssociationContributor, "Question")
+	thread.Comments = []model.Comment{
+		comment("spammer", model.AssociationNone, "Hello"),
+		reply,
+		comment("carol", model.AssociationMember, "Answer"),
+	}
+	thread.CommentsCount = 3
+	blocked := comment("spammer", model.AssociationNone, "Hello")
+	blocked.Comments = []model.Comment{comment("carol", model.AssociationMember, "Go away")}
+	blocked.CommentsCount = 1
+	ds := []model.Discussion{{Comments: []model.Comment{thread, blocked}}}
+
+	rules := moderation.Rules{BlockedLogins: []string{"spammer"}, HoldNewcomers: true}
+	got, report := rules.Apply(ds)
+	if len(got[0].Comments) != 1 {
+		t.Fatalf("want replies of the blocked comment to be left out, got %+v", got[0].Comments)
+	}
+	replies := got[0].Comments[0].Comments
+	if len(replies) != 2 || replies[0].Author.Name != "newbie" || got[0].Comments[0].CommentsCount != 2 {
+		t.Errorf("want the reply approved by the member carol kept, got %+v", got[0].Comments[0])
+	}
+	if len(report) != 2 {
+		t.Errorf("want the blocked reply and comment reported, got:\n%v", report)
+	}
+	if len(ds[0].Comments[0].Comments) != 3 {
+		t.Error("input replies must not be modified")
+	}
+}
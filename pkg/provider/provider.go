@@ -0,0 +1,56 @@
+// Package provider defines what the bridge needs from a discussion backend such as GitHub Discussions.
+package provider
+
+import (
+	"context"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+)
+
+// DiscussionProvider lists, reads and writes discussions of a backend.
+// Implementations convert the backend's data to the independent model.
+type DiscussionProvider interface {
+	// Categories returns the categories discussions can be created in.
+	Categories(ctx context.Context) (model.Categories, error)
+	// Discussions returns the discussions of the category, most recently updated first.
+	Discussions(ctx context.Context, categoryID string) ([]model.Discussion, error)
+	// Discussion returns a single discussion by its ID.
+	Discussion(ctx context.Context, id string) (*model.Discussion, error)
+	// CreateDiscussion creates a discussion in the category and returns its ID.
+	CreateDiscussion(ctx context.Context, categoryID, title, body string) (string, error)
+	// UpdateDiscussion replaces the title and body of the discussion.
+	UpdateDiscussion(ctx context.Context, id, title, body string) error
+}
+
+// Stats is optionally implemented by providers to report what a run has consumed, e.g. API rate limits.
+type Stats interface {
+	Stats() string
+}
+
+// CloseReason tells why a discussion is closed.
+type CloseReason string
+
+const (
+	CloseDuplicate CloseReason = "duplicate"
+	CloseOutdated  CloseReason = "outdated"
+)
+
+// Commenter is implemented by providers that can comment on discussions.
+type Commenter interface {
+	AddComment(ctx context.Context, id, body string) error
+}
+
+// Locker is implemented by providers that can lock discussions, so that they can no longer be commented on.
+type Locker interface {
+	LockDiscussion(ctx context.Context, id string) error
+}
+
+// Closer is implemented by providers that can close discussions.
+type Closer interface {
+	CloseDiscussion(ctx context.Context, id string, reason CloseReason) error
+}
+
+// Labeler is implemented by providers that can label discussions.
+type Labeler interface {
+	AddLabel(ctx context.Context, id, label string) error
+}
@@ -0,0 +1,87 @@
+// Package rest is a small JSON client for the REST APIs of issue trackers, e.g. Gitea and GitLab.
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type Client struct {
+	httpClient *http.Client
+	apiURL     string
+}
+
+// New creates a client for the API at apiURL, e.g. "https://codeberg.org/api/v1".
+// The HTTP client is expected to authenticate the requests, e.g. with an access token.
+func New(client *http.Client, apiURL string) *Client {
+	return &Client{httpClient: client, apiURL: apiURL}
+}
+
+// Get requests the path with the query and unmarshals the response into v.
+func (c *Client) Get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.do(ctx, http.MethodGet, path, nil, v)
+}
+
+// Send requests the path with the input as JSON body and unmarshals the response into v unless it is nil.
+func (c *Client) Send(ctx context.Context, method, path string, input interface{}, v interface{}) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return fmt.Errorf("could not marshal JSON: %w", err)
+	}
+	return c.do(ctx, method, path, bytes.NewReader(data), v)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error while reading response: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		// the message is a string for Gitea, but can also be an object for GitLab:
+		var apiErr struct {
+			Message interface{} `json:"message"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil && apiErr.Message != nil && apiErr.Message != "" {
+			return fmt.Errorf("%s: %v", resp.Status, apiErr.Message)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	if v == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("could not unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// ParseNumber parses the ID of a discussion backed by an issue, i.e. the issue's number.
+func ParseNumber(id string) (int, error) {
+	number, err := strconv.Atoi(id)
+	if err != nil {
+		return 0, fmt.Errorf("invalid issue number %q", id)
+	}
+	return number, nil
+}
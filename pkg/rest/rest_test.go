@@ -0,0 +1,48 @@
+package rest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/rest"
+)
+
+func TestClientErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		body    string
+		wantErr string
+	}{
+		{name: "message string", body: `{"message": "issue does not exist"}`, wantErr: "404 Not Found: issue does not exist"},
+		{name: "message object", body: `{"message": {"title": ["is too long"]}}`, wantErr: "404 Not Found: map[title:[is too long]]"},
+		{name: "no message", body: `not found`, wantErr: "404 Not Found"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(tc.body))
+			}))
+			defer srv.Close()
+
+			var v struct{}
+			err := rest.New(srv.Client(), srv.URL).Get(context.Background(), "/issues/1", nil, &v)
+			if err == nil || strings.TrimSpace(err.Error()) != tc.wantErr {
+				t.Errorf("want error %q, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	if n, err := rest.ParseNumber("42"); err != nil || n != 42 {
+		t.Errorf("want 42, got %d (err: %v)", n, err)
+	}
+	if _, err := rest.ParseNumber("D_kwDO"); err == nil {
+		t.Error("want error for non-numeric ID")
+	}
+}
@@ -0,0 +1,238 @@
+// Package sanitize cleans untrusted HTML so that it can be embedded into a site as-is.
+package sanitize
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Policy is an allowlist of elements and attributes that survive sanitization.
+// Everything that is not explicitly allowed is removed.
+type Policy struct {
+	// Elements maps allowed element names to their allowed attributes.
+	Elements map[string][]string
+	// Schemes are the allowed URL schemes of href and src attributes. Relative URLs are always allowed.
+	Schemes []string
+	// NoFollow adds rel="nofollow noopener noreferrer" to all links.
+	NoFollow bool
+	// LazyImages adds loading="lazy" to all images.
+	LazyImages bool
+}
+
+// dropped elements are removed together with their content. If such an element is not closed,
+// its content ends with the element that encloses it.
+var dropped = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"iframe":   true,
+	"object":   true,
+	"embed":    true,
+	"noscript": true,
+	"template": true,
+	"textarea": true,
+	"select":   true,
+	"svg":      true,
+	"math":     true,
+}
+
+var void = map[string]bool{
+	"br":  true,
+	"hr":  true,
+	"img": true,
+}
+
+// languageClass is the prefix of the only classes that are kept, e.g. "language-go" of code.
+// Other classes are removed, since they would apply the site's styles.
+const languageClass = "language-"
+
+// DefaultPolicy allows the subset of HTML that GitHub renders for comments,
+// without scripts, styles, forms, embedded content or classes other than the language of code.
+func DefaultPolicy() *Policy {
+	return &Policy{
+		Elements: map[string][]string{
+			"a":          {"href", "title"},
+			"img":        {"src", "alt", "title", "width", "height"},
+			"p":          nil,
+			"br":         nil,
+			"hr":         nil,
+			"em":         nil,
+			"strong":     nil,
+			"b":          nil,
+			"i":          nil,
+			"s":          nil,
+			"del":        nil,
+			"ins":        nil,
+			"sub":        nil,
+			"sup":        nil,
+			"kbd":        nil,
+			"blockquote": nil,
+			"code":       {"class"},
+			"pre":        nil,
+			"span":       nil,
+			"div":        nil,
+			"ul":         nil,
+			"ol":         {"start"},
+			"li":         nil,
+			"h1":         nil,
+			"h2":         nil,
+			"h3":         nil,
+			"h4":         nil,
+			"h5":         nil,
+			"h6":         nil,
+			"table":      nil,
+			"thead":      nil,
+			"tbody":      nil,
+			"tr":         nil,
+			"th":         {"align", "colspan", "rowspan"},
+			"td":         {"align", "colspan", "rowspan"},
+			"details":    {"open"},
+			"summary":    nil,
+		},
+		Schemes:    []string{"http", "https", "mailto"},
+		NoFollow:   true,
+		LazyImages: true,
+	}
+}
+
+// HTML sanitizes the given HTML fragment. Disallowed elements are stripped while keeping their text,
+// except for elements such as script or style which are removed entirely.
+// Unbalanced tags are closed, so that the result can be safely embedded into a page.
+func (p *Policy) HTML(s string) string {
+	var out bytes.Buffer
+	var open []string
+	// skip counts the open dropped elements, which started within the first skipDepth open elements:
+	skip, skipDepth := 0, 0
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			if z.Err() == io.EOF {
+				break
+			}
+			return ""
+		}
+		tok := z.Token()
+		name := tok.Data
+		switch tt {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			if dropped[name] {
+				if tt == html.StartTagToken {
+					if skip == 0 {
+						skipDepth = len(open)
+					}
+					skip++
+					// scripts and styles stay raw text, so that their code is never parsed as markup:
+					if name != "script" && name != "style" {
+						z.NextIsNotRawText()
+					}
+				}
+				continue
+			}
+			allowed, ok := p.Elements[name]
+			if skip > 0 || !ok {
+				continue
+			}
+			writeStart(&out, name, p.attributes(name, allowed, tok.Attr))
+			if tt == html.StartTagToken && !void[name] {
+				open = append(open, name)
+			}
+		case html.EndTagToken:
+			if dropped[name] {
+				if skip > 0 {
+					skip--
+				}
+				continue
+			}
+			if skip > 0 {
+				if !contains(open[:skipDepth], name) {
+					continue
+				}
+				// the enclosing element ends, and with it all unclosed dropped elements:
+				skip = 0
+			}
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != name {
+					continue
+				}
+				for j := len(open) - 1; j >= i; j-- {
+					out.WriteString("</" + open[j] + ">")
+				}
+				open = open[:i]
+				break
+			}
+		case html.TextToken:
+			if skip == 0 {
+				out.WriteString(html.EscapeString(tok.Data))
+			}
+		}
+	}
+	for i := len(open) - 1; i >= 0; i-- {
+		out.WriteString("</" + open[i] + ">")
+	}
+	return out.String()
+}
+
+func (p *Policy) attributes(element string, allowed []string, attrs []html.Attribute) []html.Attribute {
+	res := make([]html.Attribute, 0, len(attrs)+1)
+	for _, a := range attrs {
+		if a.Namespace != "" || !contains(allowed, a.Key) {
+			continue
+		}
+		if (a.Key == "href" || a.Key == "src") && !p.allowedURL(a.Val) {
+			continue
+		}
+		if a.Key == "class" {
+			if a.Val = languageClasses(a.Val); a.Val == "" {
+				continue
+			}
+		}
+		res = append(res, html.Attribute{Key: a.Key, Val: a.Val})
+	}
+	if element == "a" && p.NoFollow {
+		res = append(res, html.Attribute{Key: "rel", Val: "nofollow noopener noreferrer"})
+	}
+	if element == "img" && p.LazyImages {
+		res = append(res, html.Attribute{Key: "loading", Val: "lazy"})
+	}
+	return res
+}
+
+// languageClasses returns the language-* classes of the class attribute's value.
+func languageClasses(val string) string {
+	var classes []string
+	for _, c := range strings.Fields(val) {
+		if strings.HasPrefix(c, languageClass) {
+			classes = append(classes, c)
+		}
+	}
+	return strings.Join(classes, " ")
+}
+
+func (p *Policy) allowedURL(raw string) bool {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return false
+	}
+	return u.Scheme == "" || contains(p.Schemes, strings.ToLower(u.Scheme))
+}
+
+func writeStart(w *bytes.Buffer, name string, attrs []html.Attribute) {
+	w.WriteString("<" + name)
+	for _, a := range attrs {
+		w.WriteString(" " + a.Key + `="` + html.EscapeString(a.Val) + `"`)
+	}
+	w.WriteString(">")
+}
+
+func contains(values []string, v string) bool {
+	for _, s := range values {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package sanitize_test
+
+import (
+	"testing"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/sanitize"
+)
+
+func TestPolicyHTML(t *testing.T) {
+	testCases := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "keeps allowed markup",
+			in:   `<p>Hello <strong>world</strong></p>`,
+			want: `<p>Hello <strong>world</strong></p>`,
+		},
+		{
+			name: "drops scripts with content",
+			in:   `<p>Hi<script>alert(1)</script></p>`,
+			want: `<p>Hi</p>`,
+		},
+		{
+			name: "strips unknown elements and attributes",
+			in:   `<g-emoji alias="+1">👍</g-emoji><p onclick="x()" style="color: red">ok</p>`,
+			want: `👍<p>ok</p>`,
+		},
+		{
+			name: "adds nofollow and removes javascript links",
+			in:   `<a href="javascript:alert(1)">x</a><a href="https://gohugo.io" rel="me">y</a>`,
+			want: `<a rel="nofollow noopener noreferrer">x</a><a href="https://gohugo.io" rel="nofollow noopener noreferrer">y</a>`,
+		},
+		{
+			name: "lazy loads images",
+			in:   `<img src="https://example.com/a.png" alt="a" onerror="x()">`,
+			want: `<img src="https://example.com/a.png" alt="a" loading="lazy">`,
+		},
+		{
+			name: "closes unbalanced tags",
+			in:   `<blockquote><p>quote</div>`,
+			want: `<blockquote><p>quote</p></blockquote>`,
+		},
+		{
+			name: "keeps only language classes",
+			in:   `<div class="container"><pre class="highlight"><code class="hidden language-go">x</code></pre></div>`,
+			want: `<div><pre><code class="language-go">x</code></pre></div>`,
+		},
+		{
+			name: "unclosed textarea ends with its enclosing element",
+			in:   `<p>Hi<textarea>secret</p><p>after</p>`,
+			want: `<p>Hi</p><p>after</p>`,
+		},
+		{
+			name: "unclosed select ends with its enclosing element",
+			in:   `<ul><li><select><option>a</option></li><li>b</li></ul>`,
+			want: `<ul><li></li><li>b</li></ul>`,
+		},
+	}
+
+	policy := sanitize.DefaultPolicy()
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := policy.HTML(tc.in)
+			if got != tc.want {
+				t.Errorf("unexpected result:\n  want=%v\n   got=%v", tc.want, got)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 
 	"github.com/hugo-mods/discussions-bridge/pkg/model"
 )
@@ -38,3 +39,15 @@ func (d Discussions) URLs() []string {
 	}
 	return urls
 }
+
+// Orphans returns the URLs of pages with discussion that are not among the pages, e.g. because they have been removed.
+func (d Discussions) Orphans(pages map[string]Page) []string {
+	var urls []string
+	for url := range d {
+		if _, ok := pages[url]; !ok {
+			urls = append(urls, url)
+		}
+	}
+	sort.Strings(urls)
+	return urls
+}
@@ -0,0 +1,119 @@
+package site
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+)
+
+// Winner is the rule to choose the canonical discussion among several discussions of the same page.
+type Winner string
+
+const (
+	// WinnerOldest chooses the discussion that has been created first.
+	WinnerOldest Winner = "oldest"
+	// WinnerMostCommented chooses the discussion with the most comments, and the oldest of those.
+	WinnerMostCommented Winner = "most-commented"
+)
+
+// ParseWinner parses the name of a winner rule. An empty name results in WinnerOldest.
+func ParseWinner(name string) (Winner, error) {
+	switch w := Winner(name); w {
+	case "":
+		return WinnerOldest, nil
+	case WinnerOldest, WinnerMostCommented:
+		return w, nil
+	default:
+		return "", fmt.Errorf("unknown winner %q, want %s or %s", name, WinnerOldest, WinnerMostCommented)
+	}
+}
+
+// BridgeMarker is added to the comments the bridge writes, e.g. on the losers of duplicates,
+// so that they neither count towards the winner nor are merged into it.
+const BridgeMarker = "<!-- discussions-bridge -->"
+
+// Duplicate are several discussions related to the same page.
+type Duplicate struct {
+	Page string
+	// Winner is the canonical discussion. Its comments include those of the losers if merging is enabled.
+	Winner model.Discussion
+	Losers []model.Discussion
+}
+
+// WithWinner sets the rule to choose the canonical discussion of a page with duplicates.
+func (s *Site) WithWinner(winner Winner) *Site {
+	s.winner = winner
+	return s
+}
+
+// WithMergedDuplicates merges the comments of duplicates into the canonical discussion.
+func (s *Site) WithMergedDuplicates(enabled bool) *Site {
+	s.mergeDuplicates = enabled
+	return s
+}
+
+// rank sorts the discussions of the same page so that the winner comes first.
+// Open and unlocked discussions always rank before those that have been locked or closed, e.g. as duplicates.
+// Ties are broken by the number and ID, so that the winner is always the same.
+func (s *Site) rank(ds []model.Discussion) {
+	sort.SliceStable(ds, func(i, j int) bool {
+		a, b := &ds[i], &ds[j]
+		if active(a) != active(b) {
+			return active(a)
+		}
+		if s.winner == WinnerMostCommented {
+			if na, nb := commentsCount(a), commentsCount(b); na != nb {
+				return na > nb
+			}
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.Before(b.CreatedAt)
+		}
+		if a.Number != b.Number {
+			return a.Number < b.Number
+		}
+		return a.ID < b.ID
+	})
+}
+
+func active(d *model.Discussion) bool {
+	return !d.Locked && !d.Closed
+}
+
+// commentsCount returns the number of comments that have not been written by the bridge.
+func commentsCount(d *model.Discussion) int {
+	n := 0
+	for _, c := range d.Comments {
+		if !bridgeComment(c) {
+			n++
+		}
+	}
+	return n
+}
+
+func bridgeComment(c model.Comment) bool {
+	return strings.Contains(c.Body, BridgeMarker)
+}
+
+// merge returns the winner with the comments of all discussions, answers first and then in chronological order.
+// Comments the bridge has written on the losers are left out.
+func merge(winner model.Discussion, losers []model.Discussion) model.Discussion {
+	comments := append([]model.Comment{}, winner.Comments...)
+	for _, l := range losers {
+		for _, c := range l.Comments {
+			if !bridgeComment(c) {
+				comments = append(comments, c)
+			}
+		}
+	}
+	sort.SliceStable(comments, func(i, j int) bool {
+		if comments[i].Answer != comments[j].Answer {
+			return comments[i].Answer
+		}
+		return comments[i].CreatedAt.Before(comments[j].CreatedAt)
+	})
+	winner.Comments = comments
+	return winner
+}
@@ -0,0 +1,173 @@
+package site
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+)
+
+// Reconciliation is the state of the relation between pages and discussions.
+type Reconciliation struct {
+	// Synced are the pages with exactly one discussion.
+	Synced []Thread `json:"synced"`
+	// Missing are the pages without discussion.
+	Missing []Page `json:"missing"`
+	// Orphans are discussions of pages that are no longer on the site. They are only detected by the opener relation,
+	// since it reads the page's URL from the discussion. The other relations only match pages that are still on the site.
+	Orphans []Thread `json:"orphans"`
+	// Duplicates are discussions of pages with more than one discussion.
+	Duplicates []Thread `json:"duplicates"`
+	// Unrelated are discussions that do not match any relation, e.g. because the opener has been changed.
+	Unrelated []Thread `json:"unrelated"`
+}
+
+// Thread summarises a discussion and the page it is related to (if any).
+type Thread struct {
+	Page     string `json:"page,omitempty"`
+	URL      string `json:"url"`
+	Number   int    `json:"number"`
+	Title    string `json:"title"`
+	Comments int    `json:"comments"`
+	// Canonical is true for the winner among duplicates.
+	Canonical bool `json:"canonical,omitempty"`
+}
+
+func newThread(page string, d *model.Discussion) Thread {
+	return Thread{Page: page, URL: d.URL, Number: d.Number, Title: d.Title, Comments: len(d.Comments)}
+}
+
+// Reconcile relates the discussions to the pages like Relate, but reports every discussion instead of
+// keeping only one per page.
+func (s *Site) Reconcile(ds []model.Discussion, pages map[string]Page) Reconciliation {
+	sorted := sortedPages(pages)
+	byPage := make(map[string][]model.Discussion, len(ds))
+	r := Reconciliation{Synced: []Thread{}, Missing: []Page{}, Orphans: []Thread{}, Duplicates: []Thread{}, Unrelated: []Thread{}}
+	for i := range ds {
+		url := s.relateAny(&ds[i], sorted)
+		switch _, onSite := pages[url]; {
+		case url == "":
+			r.Unrelated = append(r.Unrelated, newThread("", &ds[i]))
+		case !onSite:
+			r.Orphans = append(r.Orphans, newThread(url, &ds[i]))
+		default:
+			byPage[url] = append(byPage[url], ds[i])
+		}
+	}
+	for _, p := range sorted {
+		switch candidates := byPage[p.URL]; len(candidates) {
+		case 0:
+			r.Missing = append(r.Missing, p)
+		case 1:
+			r.Synced = append(r.Synced, newThread(p.URL, &candidates[0]))
+		default:
+			s.rank(candidates)
+			for i := range candidates {
+				t := newThread(p.URL, &candidates[i])
+				t.Canonical = i == 0
+				r.Duplicates = append(r.Duplicates, t)
+			}
+		}
+	}
+	sortThreads(r.Orphans)
+	sortThreads(r.Unrelated)
+	return r
+}
+
+func sortThreads(ts []Thread) {
+	sort.SliceStable(ts, func(i, j int) bool {
+		if ts[i].Page != ts[j].Page {
+			return ts[i].Page < ts[j].Page
+		}
+		return ts[i].Number < ts[j].Number
+	})
+}
+
+// Formats of the reconciliation report.
+const (
+	FormatTable    = "table"
+	FormatJSON     = "json"
+	FormatMarkdown = "markdown"
+)
+
+// Write writes the report in the format.
+func (r *Reconciliation) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatTable, "":
+		return r.writeTable(w)
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case FormatMarkdown:
+		return r.writeMarkdown(w)
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// rows returns the report as rows of state, page and discussion.
+func (r *Reconciliation) rows() [][3]string {
+	var rows [][3]string
+	discussion := func(t Thread) string {
+		fields := []string{fmt.Sprintf("#%d", t.Number)}
+		if t.URL != "" {
+			fields = append(fields, t.URL)
+		}
+		return strings.Join(append(fields, fmt.Sprintf("(%d comments)", t.Comments)), " ")
+	}
+	for _, t := range r.Synced {
+		rows = append(rows, [3]string{"synced", t.Page, discussion(t)})
+	}
+	for _, p := range r.Missing {
+		rows = append(rows, [3]string{"missing", p.URL, ""})
+	}
+	for _, t := range r.Duplicates {
+		state := "duplicate"
+		if t.Canonical {
+			state = "canonical"
+		}
+		rows = append(rows, [3]string{state, t.Page, discussion(t)})
+	}
+	for _, t := range r.Orphans {
+		rows = append(rows, [3]string{"orphan", t.Page, discussion(t)})
+	}
+	for _, t := range r.Unrelated {
+		rows = append(rows, [3]string{"unrelated", "", discussion(t)})
+	}
+	return rows
+}
+
+func (r *Reconciliation) summary() string {
+	return fmt.Sprintf("%d synced, %d missing, %d duplicates, %d orphans, %d unrelated",
+		len(r.Synced), len(r.Missing), len(r.Duplicates), len(r.Orphans), len(r.Unrelated))
+}
+
+func (r *Reconciliation) writeTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "STATE\tPAGE\tDISCUSSION")
+	for _, row := range r.rows() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", row[0], row[1], row[2])
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, r.summary())
+	return err
+}
+
+func (r *Reconciliation) writeMarkdown(w io.Writer) error {
+	escape := strings.NewReplacer("|", `\|`).Replace
+	var b strings.Builder
+	b.WriteString("| State | Page | Discussion |\n|---|---|---|\n")
+	for _, row := range r.rows() {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", row[0], escape(row[1]), escape(row[2]))
+	}
+	fmt.Fprintf(&b, "\n%s\n", r.summary())
+	_, err := io.WriteString(w, b.String())
+	return err
+}
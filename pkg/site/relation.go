@@ -0,0 +1,164 @@
+package site
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/hugo-mods/discussions-bridge/pkg/model"
+)
+
+// Relation is a strategy to relate a discussion to a page.
+// Apart from the opener, they are compatible with the mappings of giscus and utterances,
+// so that threads created by them are adopted instead of duplicated when migrating.
+type Relation string
+
+const (
+	// RelationOpener finds the page's URL in the discussion body using the opener.
+	RelationOpener Relation = "opener"
+	// RelationPathname matches the discussion title against the page's path, e.g. "blog/icons/".
+	RelationPathname Relation = "pathname"
+	// RelationURL matches the discussion title against the page's URL.
+	RelationURL Relation = "url"
+	// RelationTitle matches the discussion title against the page's title,
+	// which may be followed by the site's name, e.g. "Icons | My Blog".
+	RelationTitle Relation = "title"
+	// RelationOGTitle is like RelationTitle. Hugo uses the page's title as og:title by default.
+	RelationOGTitle Relation = "og:title"
+	// RelationSpecific matches the discussion title against the term configured for the page.
+	RelationSpecific Relation = "specific"
+	// RelationSHA1 finds the SHA-1 hash of any of the above terms in the marker that giscus
+	// adds to the discussion body in strict mode, e.g. "<!-- sha1: 3f78... -->".
+	RelationSHA1 Relation = "sha1"
+)
+
+var relations = []Relation{RelationOpener, RelationPathname, RelationURL, RelationTitle, RelationOGTitle, RelationSpecific, RelationSHA1}
+
+// ParseRelations parses the names of relations. No names result in the opener relation.
+func ParseRelations(names []string) ([]Relation, error) {
+	if len(names) == 0 {
+		return []Relation{RelationOpener}, nil
+	}
+	rs := make([]Relation, 0, len(names))
+	for _, name := range names {
+		r := Relation(strings.ToLower(strings.TrimSpace(name)))
+		valid := false
+		for _, known := range relations {
+			valid = valid || r == known
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown relation %q, want one of %v", name, relations)
+		}
+		rs = append(rs, r)
+	}
+	return rs, nil
+}
+
+// titleSeparators separate the page's title from the site's name in the document title.
+var titleSeparators = []string{" | ", " - ", " – ", " — ", " · ", " :: "}
+
+var sha1MarkerRE = regexp.MustCompile(`<!--\s*sha1:\s*([0-9a-fA-F]{40})\s*-->`)
+
+// Pathname returns the page's path the way giscus and utterances use it as term,
+// i.e. without leading slash and file extension, and "index" for the root.
+func Pathname(pageURL string) string {
+	path := pageURL
+	if u, err := url.Parse(pageURL); err == nil {
+		path = u.Path
+	}
+	if len(path) < 2 {
+		return "index"
+	}
+	path = strings.TrimPrefix(path, "/")
+	if i := strings.LastIndex(path, "."); i > strings.LastIndex(path, "/") {
+		path = path[:i]
+	}
+	return path
+}
+
+// relate returns the URL of the page the discussion belongs to according to the relation, or an empty string.
+func (s *Site) relate(r Relation, d *model.Discussion, pages []Page) string {
+	switch r {
+	case RelationOpener:
+		if subs := s.openerURLRegEx.FindStringSubmatch(d.Body); len(subs) > 1 {
+			return subs[1]
+		}
+		return ""
+	case RelationSHA1:
+		subs := sha1MarkerRE.FindStringSubmatch(d.Body)
+		if len(subs) < 2 {
+			return ""
+		}
+		for _, p := range pages {
+			for _, term := range s.terms(p) {
+				if hash(term) == strings.ToLower(subs[1]) {
+					return p.URL
+				}
+			}
+		}
+		return ""
+	}
+	for _, p := range pages {
+		if s.matches(r, d.Title, p) {
+			return p.URL
+		}
+	}
+	return ""
+}
+
+func (s *Site) matches(r Relation, title string, p Page) bool {
+	title = strings.TrimSpace(title)
+	switch r {
+	case RelationPathname:
+		return strings.Trim(title, "/") == strings.Trim(Pathname(p.URL), "/")
+	case RelationURL:
+		return normalizeURL(title) == normalizeURL(p.URL)
+	case RelationTitle, RelationOGTitle:
+		if p.Title == "" {
+			return false
+		}
+		if title == p.Title {
+			return true
+		}
+		for _, sep := range titleSeparators {
+			if strings.HasPrefix(title, p.Title+sep) || strings.HasSuffix(title, sep+p.Title) {
+				return true
+			}
+		}
+		return false
+	case RelationSpecific:
+		term, ok := s.relationTerms[p.URL]
+		return ok && title == term
+	}
+	return false
+}
+
+// terms returns the terms giscus may have hashed for the page.
+func (s *Site) terms(p Page) []string {
+	terms := []string{Pathname(p.URL), p.URL}
+	if p.Title != "" {
+		terms = append(terms, p.Title)
+	}
+	if term, ok := s.relationTerms[p.URL]; ok {
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+func hash(term string) string {
+	sum := sha1.Sum([]byte(term))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeURL ignores the query, fragment and trailing slash of the URL.
+func normalizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.RawQuery, u.Fragment = "", ""
+	return strings.TrimSuffix(u.String(), "/")
+}
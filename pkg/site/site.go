@@ -2,12 +2,14 @@ package site
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"fmt"
 	"html/template"
 	"io"
 	"net/http"
 	"regexp"
+	"sort"
 	"strings"
 	"time"
 
@@ -15,10 +17,15 @@ import (
 )
 
 type Site struct {
-	SitemapURL     string
-	RSSURL         string
-	openerTemplate *template.Template
-	openerURLRegEx *regexp.Regexp
+	SitemapURL      string
+	RSSURL          string
+	client          *http.Client
+	openerTemplate  *template.Template
+	openerURLRegEx  *regexp.Regexp
+	relations       []Relation
+	relationTerms   map[string]string
+	winner          Winner
+	mergeDuplicates bool
 }
 
 func New(sitemapURL string, rssURL string, opener string) (*Site, error) {
@@ -43,23 +50,101 @@ func New(sitemapURL string, rssURL string, opener string) (*Site, error) {
 	return &Site{
 		SitemapURL:     sitemapURL,
 		RSSURL:         rssURL,
+		client:         http.DefaultClient,
 		openerTemplate: template,
 		openerURLRegEx: openerRE,
+		relations:      []Relation{RelationOpener},
+		winner:         WinnerOldest,
 	}, nil
 }
 
-func (s *Site) RelateDiscussions(ds []model.Discussion) Discussions {
-	sds := make(Discussions, len(ds))
-	for _, d := range ds {
-		subs := s.openerURLRegEx.FindStringSubmatch(d.Message.Body)
-		if len(subs) > 1 {
-			url := subs[1]
-			sds[url] = d
+// WithHTTPClient sets the client used to retrieve the RSS feed and sitemap, e.g. to configure timeouts.
+func (s *Site) WithHTTPClient(client *http.Client) *Site {
+	s.client = client
+	return s
+}
+
+// WithRelations sets the strategies to relate discussions to pages. They are tried in the given order.
+func (s *Site) WithRelations(relations ...Relation) *Site {
+	s.relations = relations
+	return s
+}
+
+// WithRelationTerms sets the terms of pages (by URL) for the specific relation.
+func (s *Site) WithRelationTerms(terms map[string]string) *Site {
+	s.relationTerms = terms
+	return s
+}
+
+// NeedsPages reports whether any of the relations needs the site's pages.
+func (s *Site) NeedsPages() bool {
+	for _, r := range s.relations {
+		if r != RelationOpener {
+			return true
 		}
 	}
+	return false
+}
+
+// RelateDiscussions relates discussions to pages without knowing the pages, which is sufficient for the opener relation.
+func (s *Site) RelateDiscussions(ds []model.Discussion) Discussions {
+	return s.Relate(ds, nil)
+}
+
+// Relate relates discussions to the pages using the first relation that matches.
+// Discussions without a matching page are left out. Of several discussions of a page, only the winner is kept.
+func (s *Site) Relate(ds []model.Discussion, pages map[string]Page) Discussions {
+	sds, _ := s.RelateWithDuplicates(ds, pages)
 	return sds
 }
 
+// RelateWithDuplicates is like Relate, but additionally returns the pages with several discussions, ordered by page.
+func (s *Site) RelateWithDuplicates(ds []model.Discussion, pages map[string]Page) (Discussions, []Duplicate) {
+	sorted := sortedPages(pages)
+	byPage := make(map[string][]model.Discussion, len(ds))
+	for i := range ds {
+		if url := s.relateAny(&ds[i], sorted); url != "" {
+			byPage[url] = append(byPage[url], ds[i])
+		}
+	}
+	sds := make(Discussions, len(byPage))
+	var duplicates []Duplicate
+	for url, candidates := range byPage {
+		if len(candidates) == 1 {
+			sds[url] = candidates[0]
+			continue
+		}
+		s.rank(candidates)
+		winner, losers := candidates[0], candidates[1:]
+		if s.mergeDuplicates {
+			winner = merge(winner, losers)
+		}
+		sds[url] = winner
+		duplicates = append(duplicates, Duplicate{Page: url, Winner: winner, Losers: losers})
+	}
+	sort.Slice(duplicates, func(i, j int) bool { return duplicates[i].Page < duplicates[j].Page })
+	return sds, duplicates
+}
+
+// relateAny returns the URL of the page the discussion belongs to according to the first matching relation.
+func (s *Site) relateAny(d *model.Discussion, pages []Page) string {
+	for _, r := range s.relations {
+		if url := s.relate(r, d, pages); url != "" {
+			return url
+		}
+	}
+	return ""
+}
+
+func sortedPages(pages map[string]Page) []Page {
+	sorted := make([]Page, 0, len(pages))
+	for _, p := range pages {
+		sorted = append(sorted, p)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].URL < sorted[j].URL })
+	return sorted
+}
+
 type Page struct {
 	URL         string
 	Title       string
@@ -88,17 +173,17 @@ func (s *Site) NewDiscussion(p Page) (*model.Discussion, error) {
 }
 
 // Pages tries to collect the site's pages by first trying RSS and then Sitemap.
-func (s *Site) Pages(urlPrefix string) (map[string]Page, error) {
+func (s *Site) Pages(ctx context.Context, urlPrefix string) (map[string]Page, error) {
 	var err error
 	var pages map[string]Page
 	if s.RSSURL != "" {
-		pages, err = s.RSS(urlPrefix)
+		pages, err = s.RSS(ctx, urlPrefix)
 		if err == nil {
 			return pages, nil
 		}
 	}
 	if s.SitemapURL != "" {
-		pages, err = s.Sitemap(urlPrefix)
+		pages, err = s.Sitemap(ctx, urlPrefix)
 		if err == nil {
 			return pages, nil
 		}
@@ -106,16 +191,11 @@ func (s *Site) Pages(urlPrefix string) (map[string]Page, error) {
 	return nil, fmt.Errorf("could not get pages: %v", err)
 }
 
-func (s *Site) Sitemap(urlPrefix string) (map[string]Page, error) {
-	resp, err := http.Get(s.SitemapURL)
+func (s *Site) Sitemap(ctx context.Context, urlPrefix string) (map[string]Page, error) {
+	body, err := s.get(ctx, s.SitemapURL)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error while reading response: %w", err)
-	}
 
 	type Sitemap struct {
 		XMLName xml.Name `xml:"urlset"`
@@ -139,16 +219,11 @@ func (s *Site) Sitemap(urlPrefix string) (map[string]Page, error) {
 	return result, nil
 }
 
-func (s *Site) RSS(urlPrefix string) (map[string]Page, error) {
-	resp, err := http.Get(s.RSSURL)
+func (s *Site) RSS(ctx context.Context, urlPrefix string) (map[string]Page, error) {
+	body, err := s.get(ctx, s.RSSURL)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error while reading response: %w", err)
-	}
 
 	type Sitemap struct {
 		XMLName xml.Name `xml:"rss"`
@@ -185,3 +260,35 @@ func (s *Site) RSS(urlPrefix string) (map[string]Page, error) {
 	}
 	return result, nil
 }
+
+// Removed reports whether the page is gone, i.e. its URL responds with 404 Not Found or 410 Gone.
+// Pages that are only missing from the feed or sitemap, e.g. because it is truncated, are not removed.
+func (s *Site) Removed(ctx context.Context, url string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone, nil
+}
+
+func (s *Site) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error while reading response: %w", err)
+	}
+	return body, nil
+}
@@ -1,13 +1,45 @@
 package site_test
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hugo-mods/discussions-bridge/pkg/model"
 	"github.com/hugo-mods/discussions-bridge/pkg/site"
 )
 
+const opener = "Blog post: {{ .URL }}"
+
+// at returns a time on the given day of January 2022.
+func at(day int) time.Time {
+	return time.Date(2022, 1, day, 0, 0, 0, 0, time.UTC)
+}
+
+// discussion returns a discussion created at the day with the body, e.g. the opener of a page, and the comments.
+func discussion(number, day int, body string, comments ...model.Comment) model.Discussion {
+	return model.Discussion{
+		Message:  model.Message{Number: number, CreatedAt: at(day), Body: body},
+		Comments: comments,
+	}
+}
+
+func comment(body string, day int) model.Comment {
+	return model.Comment{Message: model.Message{Body: body, CreatedAt: at(day)}}
+}
+
+func newSite(t *testing.T) *site.Site {
+	s, err := site.New("", "", opener)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
 func TestInferDiscussion(t *testing.T) {
 	testCases := []struct {
 		opener      string
@@ -41,3 +73,196 @@ func TestInferDiscussion(t *testing.T) {
 		})
 	}
 }
+
+func TestRelate(t *testing.T) {
+	pages := map[string]site.Page{
+		"https://hugo-mods.github.io/blog/icons/": {URL: "https://hugo-mods.github.io/blog/icons/", Title: "Icons"},
+		"https://hugo-mods.github.io/blog/lazy/":  {URL: "https://hugo-mods.github.io/blog/lazy/", Title: "Lazy Images"},
+		"https://hugo-mods.github.io/":            {URL: "https://hugo-mods.github.io/", Title: "Home"},
+	}
+	testCases := []struct {
+		name       string
+		relations  []site.Relation
+		discussion model.Discussion
+		want       string
+	}{
+		{
+			name:       "opener",
+			relations:  []site.Relation{site.RelationOpener},
+			discussion: model.Discussion{Message: model.Message{Body: "Blog post: https://hugo-mods.github.io/blog/icons/"}},
+			want:       "https://hugo-mods.github.io/blog/icons/",
+		},
+		{
+			name:       "pathname",
+			relations:  []site.Relation{site.RelationPathname},
+			discussion: model.Discussion{Title: "blog/lazy/"},
+			want:       "https://hugo-mods.github.io/blog/lazy/",
+		},
+		{
+			name:       "pathname of root",
+			relations:  []site.Relation{site.RelationPathname},
+			discussion: model.Discussion{Title: "index"},
+			want:       "https://hugo-mods.github.io/",
+		},
+		{
+			name:       "url without trailing slash",
+			relations:  []site.Relation{site.RelationURL},
+			discussion: model.Discussion{Title: "https://hugo-mods.github.io/blog/icons"},
+			want:       "https://hugo-mods.github.io/blog/icons/",
+		},
+		{
+			name:       "title with site name",
+			relations:  []site.Relation{site.RelationTitle},
+			discussion: model.Discussion{Title: "Lazy Images | hugo-mods"},
+			want:       "https://hugo-mods.github.io/blog/lazy/",
+		},
+		{
+			name:       "specific term",
+			relations:  []site.Relation{site.RelationSpecific},
+			discussion: model.Discussion{Title: "icons-comments"},
+			want:       "https://hugo-mods.github.io/blog/icons/",
+		},
+		{
+			name:      "sha1 marker of pathname",
+			relations: []site.Relation{site.RelationSHA1},
+			// sha1("blog/icons/")
+			discussion: model.Discussion{Title: "something else", Message: model.Message{Body: "Comments\n\n<!-- sha1: 9a849d86c42a6fd843a6eca4a92b3affc017cd41 -->"}},
+			want:       "https://hugo-mods.github.io/blog/icons/",
+		},
+		{
+			name:       "first matching relation wins",
+			relations:  []site.Relation{site.RelationOpener, site.RelationPathname},
+			discussion: model.Discussion{Title: "blog/lazy/", Message: model.Message{Body: "Blog post: https://hugo-mods.github.io/blog/icons/"}},
+			want:       "https://hugo-mods.github.io/blog/icons/",
+		},
+		{
+			name:       "no match",
+			relations:  []site.Relation{site.RelationPathname, site.RelationTitle},
+			discussion: model.Discussion{Title: "General feedback"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newSite(t).WithRelations(tc.relations...).
+				WithRelationTerms(map[string]string{"https://hugo-mods.github.io/blog/icons/": "icons-comments"})
+			got := s.Relate([]model.Discussion{tc.discussion}, pages)
+			if tc.want == "" {
+				if len(got) != 0 {
+					t.Errorf("want no relation, got %v", got)
+				}
+				return
+			}
+			if !got.HasPage(tc.want) {
+				t.Errorf("want relation to %s, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	pages := map[string]site.Page{
+		"https://hugo-mods.github.io/blog/icons/": {URL: "https://hugo-mods.github.io/blog/icons/"},
+		"https://hugo-mods.github.io/blog/lazy/":  {URL: "https://hugo-mods.github.io/blog/lazy/"},
+		"https://hugo-mods.github.io/blog/new/":   {URL: "https://hugo-mods.github.io/blog/new/"},
+	}
+	ds := []model.Discussion{
+		discussion(1, 1, "Blog post: https://hugo-mods.github.io/blog/icons/"),
+		discussion(2, 1, "Blog post: https://hugo-mods.github.io/blog/lazy/"),
+		discussion(3, 1, "Blog post: https://hugo-mods.github.io/blog/lazy/"),
+		discussion(4, 1, "Blog post: https://hugo-mods.github.io/blog/removed/"),
+		discussion(5, 1, "General feedback"),
+	}
+
+	got := newSite(t).Reconcile(ds, pages)
+	want := site.Reconciliation{
+		Synced:  []site.Thread{{Page: "https://hugo-mods.github.io/blog/icons/", Number: 1}},
+		Missing: []site.Page{{URL: "https://hugo-mods.github.io/blog/new/"}},
+		Duplicates: []site.Thread{
+			{Page: "https://hugo-mods.github.io/blog/lazy/", Number: 2, Canonical: true},
+			{Page: "https://hugo-mods.github.io/blog/lazy/", Number: 3},
+		},
+		Orphans:   []site.Thread{{Page: "https://hugo-mods.github.io/blog/removed/", Number: 4}},
+		Unrelated: []site.Thread{{Number: 5}},
+	}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("unexpected reconciliation:\n  want=%+v\n   got=%+v", want, got)
+	}
+
+	var md strings.Builder
+	if err := got.Write(&md, site.FormatMarkdown); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(md.String(), "| orphan | https://hugo-mods.github.io/blog/removed/ | #4 (0 comments) |") {
+		t.Errorf("want orphan row in markdown, got:\n%s", md.String())
+	}
+	if err := got.Write(&md, "yaml"); err == nil {
+		t.Error("want error for unknown format")
+	}
+}
+
+func TestRelateWithDuplicates(t *testing.T) {
+	const body = "Blog post: https://hugo-mods.github.io/blog/icons/"
+	ds := []model.Discussion{
+		discussion(7, 3, body, comment("c", 4), comment("e", 6)),
+		discussion(5, 1, body, comment("d", 5)),
+		discussion(6, 1, body),
+	}
+	testCases := []struct {
+		name         string
+		winner       site.Winner
+		merge        bool
+		wantNumber   int
+		wantComments []string
+	}{
+		{name: "oldest by number", winner: site.WinnerOldest, wantNumber: 5, wantComments: []string{"d"}},
+		{name: "most commented", winner: site.WinnerMostCommented, wantNumber: 7, wantComments: []string{"c", "e"}},
+		{name: "oldest with merged comments", winner: site.WinnerOldest, merge: true, wantNumber: 5, wantComments: []string{"c", "d", "e"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newSite(t).WithWinner(tc.winner).WithMergedDuplicates(tc.merge)
+			sds, duplicates := s.RelateWithDuplicates(ds, nil)
+			got := sds["https://hugo-mods.github.io/blog/icons/"]
+			if got.Number != tc.wantNumber {
+				t.Errorf("want winner #%d, got #%d", tc.wantNumber, got.Number)
+			}
+			var bodies []string
+			for _, c := range got.Comments {
+				bodies = append(bodies, c.Body)
+			}
+			if !reflect.DeepEqual(tc.wantComments, bodies) {
+				t.Errorf("want comments %v, got %v", tc.wantComments, bodies)
+			}
+			if len(duplicates) != 1 || len(duplicates[0].Losers) != 2 {
+				t.Errorf("want one page with two losers, got %+v", duplicates)
+			}
+		})
+	}
+}
+
+func TestRemoved(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/blog/removed/":
+			w.WriteHeader(http.StatusNotFound)
+		case "/blog/gone/":
+			w.WriteHeader(http.StatusGone)
+		case "/blog/broken/":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer srv.Close()
+	s := newSite(t)
+
+	for path, want := range map[string]bool{"/blog/removed/": true, "/blog/gone/": true, "/blog/broken/": false, "/blog/icons/": false} {
+		got, err := s.Removed(context.Background(), srv.URL+path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("%s: want removed=%v, got %v", path, want, got)
+		}
+	}
+}